@@ -14,32 +14,109 @@ import (
 	"github.com/go-chi/httprate"
 
 	"server/internal/client"
+	"server/internal/client/catalog"
 	"server/internal/client/kv"
+	"server/internal/client/local"
 	"server/internal/client/nova"
 	"server/internal/client/simbad"
+	"server/internal/client/vizier"
 	"server/internal/config"
 	"server/internal/controller"
+	"server/internal/repository"
+	"server/internal/repository/kvstore"
+	"server/internal/repository/memory"
+	"server/internal/service"
 	"server/internal/service/solve"
+	"server/internal/service/webhook"
+	"server/internal/util/breaker"
 	"server/internal/util/httputil"
 )
 
+// jobRetentionSeconds is how long job, group and upload records are kept in KV.
+const jobRetentionSeconds = 7 * 24 * 3600
+
+// catalogCacheSeconds is how long catalog answers are cached in KV.
+const catalogCacheSeconds = 30 * 24 * 3600
+
+// catalogMissCacheSeconds is how long a name a catalog does not know is
+// remembered, short enough that objects added upstream are picked up.
+const catalogMissCacheSeconds = 24 * 3600
+
 func main() {
 	cfg := config.Load()
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
-	novaClient := nova.NewClient(cfg.Nova)
+	novaBreaker := breaker.New("nova", cfg.Nova.Breaker)
+	breakers := []*breaker.Breaker{novaBreaker}
+
+	novaClient := client.NewBreakerNovaClient(nova.NewClient(cfg.Nova), novaBreaker)
+	novaSessions := nova.NewSessionManager(novaClient, cfg.Nova.APIKey, cfg.Nova.SessionTTL)
 
-	var simbadClient client.SimbadClient = simbad.NewClient(cfg.Simbad)
+	var kvClient kv.Client
+	var jobRepository repository.JobRepository = memory.NewJobRepository()
+	var groupRepository repository.GroupRepository = memory.NewGroupRepository()
+	var uploadRepository repository.UploadRepository = memory.NewUploadRepository()
 	if cfg.KV.Enabled {
-		kvClient := kv.NewClient(cfg.KV)
-		simbadClient = simbad.NewCachedClient(simbadClient, kvClient, 30*24*3600)
+		kvBreaker := breaker.New("kv", cfg.KV.Breaker)
+		breakers = append(breakers, kvBreaker)
+		kvClient = client.NewBreakerKVClient(kv.NewClient(cfg.KV), kvBreaker)
+		jobRepository = kvstore.NewJobRepository(kvClient, jobRetentionSeconds)
+		groupRepository = kvstore.NewGroupRepository(kvClient, jobRetentionSeconds)
+		uploadRepository = kvstore.NewUploadRepository(kvClient, jobRetentionSeconds)
+	}
+
+	// Remote catalogs sit behind their own breaker and, with KV, a cache
+	// whose versioned prefix keeps them from reading each other's entries.
+	remote := func(name, prefix string, inner client.CatalogResolver, cb config.BreakerConfig) catalog.Backend {
+		b := breaker.New(name, cb)
+		breakers = append(breakers, b)
+		var resolver client.CatalogResolver = client.NewBreakerCatalogResolver(inner, b)
+		if kvClient != nil {
+			resolver = catalog.NewCachedResolver(resolver, kvClient, prefix, catalogCacheSeconds, catalogMissCacheSeconds)
+		}
+		return catalog.Backend{Name: name, Resolver: resolver}
+	}
+	var backends []catalog.Backend
+	for _, name := range cfg.Catalog.Resolvers {
+		switch name {
+		case "simbad":
+			backends = append(backends, remote(name, "simbad:v2:", simbad.NewClient(cfg.Simbad), cfg.Simbad.Breaker))
+		case "vizier":
+			backends = append(backends, remote(name, "vizier:v1:", vizier.NewClient(cfg.Vizier), cfg.Vizier.Breaker))
+		case "local":
+			if cfg.Catalog.LocalFile == "" {
+				log.Printf("catalog resolver local skipped: CATALOG_LOCAL_FILE is not set")
+				continue
+			}
+			localCatalog, err := local.Load(cfg.Catalog.LocalFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			backends = append(backends, catalog.Backend{Name: name, Resolver: localCatalog})
+		default:
+			log.Fatalf("unknown catalog resolver %q", name)
+		}
+	}
+	catalogResolver := catalog.NewChain(backends...)
+
+	var notifier service.CompletionNotifier
+	if cfg.Webhook.Enabled {
+		notifier = webhook.NewDispatcher(cfg.Webhook)
 	}
 
-	solveService := solve.NewService(novaClient, simbadClient, cfg.Nova.APIKey)
+	solveService := solve.NewService(novaClient, novaSessions, catalogResolver, jobRepository, groupRepository, uploadRepository, notifier, cfg.Solve)
+	objectService := solve.NewObjectService(catalogResolver)
+	go func() {
+		if err := solveService.ResumeCallbacks(context.Background()); err != nil {
+			log.Printf("resume webhooks: %v", err)
+		}
+	}()
 
-	solveController := controller.NewSolveController(solveService)
+	healthController := controller.NewHealthController(breakers...)
+	objectController := controller.NewObjectController(objectService)
+	solveController := controller.NewSolveController(solveService, cfg.Solve.BatchMaxImages, cfg.Solve.MaxUploadBytes, cfg.Solve.MaxBatchBytes)
 
 	router := chi.NewRouter()
 	router.Use(httprate.LimitByIP(100, time.Second))
@@ -50,10 +127,19 @@ func main() {
 		}
 	})
 
+	router.Get("/api/health", httputil.ErrorHandler(healthController.Health))
 	router.Get("/api/constellations", httputil.ErrorHandler(controller.SearchConstellations))
+	router.Get("/api/objects/{identifier}", httputil.ErrorHandler(objectController.GetObject))
+	router.Get("/api/sky/cone", httputil.ErrorHandler(objectController.ConeSearch))
 
 	router.Post("/api/solve", httputil.ErrorHandler(solveController.SubmitImage))
+	router.Post("/api/solve/url", httputil.ErrorHandler(solveController.SubmitURL))
+	router.Post("/api/solve/batch", httputil.ErrorHandler(solveController.SubmitBatch))
+	router.Get("/api/solve/batch/{groupId}", httputil.ErrorHandler(solveController.GetBatchStatus))
 	router.Get("/api/solve/{jobId}", httputil.ErrorHandler(solveController.GetSolveStatus))
+	router.Get("/api/solve/{jobId}/events", httputil.ErrorHandler(solveController.StreamSolveEvents))
+	router.Get("/api/solve/{jobId}/pixel-to-sky", httputil.ErrorHandler(solveController.PixelToSky))
+	router.Get("/api/solve/{jobId}/sky-to-pixel", httputil.ErrorHandler(solveController.SkyToPixel))
 	router.Delete("/api/solve/{jobId}", httputil.ErrorHandler(solveController.CancelSolve))
 
 	server := &http.Server{
@@ -62,6 +148,7 @@ func main() {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
+	server.RegisterOnShutdown(solveService.Close)
 
 	go func() {
 		log.Printf("Server listening on %s\n", server.Addr)
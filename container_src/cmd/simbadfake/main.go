@@ -0,0 +1,31 @@
+// Command simbadfake serves the simbadtest fake of SIMBAD's TAP service so
+// the server can run locally without the public service:
+//
+//	go run ./cmd/simbadfake -addr :8090 &
+//	SIMBAD_BASE_URL=http://localhost:8090/simbad/sim-tap/sync go run ./cmd/server
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"server/internal/client/simbad/simbadtest"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	latency := flag.Duration("latency", 0, "delay before every reply")
+	rate := flag.Int("rate", 0, "requests allowed per second before answering 429 (0 for no limit)")
+	flag.Parse()
+
+	h := simbadtest.NewHandler(simbadtest.DefaultObjects()...)
+	h.SetLatency(*latency)
+	h.SetRateLimit(*rate, time.Second)
+
+	log.Printf("Fake SIMBAD listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, h); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"server/internal/client/catalog"
+	"server/internal/client/kv"
+	"server/internal/client/nova"
+	apperrors "server/internal/errors"
+	"server/internal/util/breaker"
+	"server/internal/util/httputil"
+)
+
+var (
+	_ NovaClient      = (*BreakerNovaClient)(nil)
+	_ CatalogResolver = (*BreakerCatalogResolver)(nil)
+	_ kv.Client       = (*BreakerKVClient)(nil)
+)
+
+// BreakerNovaClient fails fast with a breaker.OpenError while Nova is
+// unavailable.
+type BreakerNovaClient struct {
+	inner   NovaClient
+	breaker *breaker.Breaker
+}
+
+func NewBreakerNovaClient(inner NovaClient, b *breaker.Breaker) *BreakerNovaClient {
+	return &BreakerNovaClient{inner: inner, breaker: b}
+}
+
+// novaOutcome counts only the errors that say Nova itself is unhealthy:
+// transport errors, timeouts and 5xx responses. Nova rejecting a request,
+// such as an expired session or an image it cannot use, and failures
+// reading the caller's own upload leave the breaker as it is, so bad
+// uploads cannot open it for everyone.
+func novaOutcome(err error) error {
+	if err == nil {
+		return nil
+	}
+	var statusErr *httputil.StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode >= http.StatusInternalServerError {
+			return err
+		}
+		return nil
+	}
+	var apiErr *apperrors.APIError
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &apiErr) || errors.As(err, &tooLarge) {
+		return nil
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+func (c *BreakerNovaClient) Login(ctx context.Context, apiKey string) (string, error) {
+	return breaker.Call(c.breaker, func() (string, error) {
+		return c.inner.Login(ctx, apiKey)
+	}, novaOutcome)
+}
+
+func (c *BreakerNovaClient) Upload(ctx context.Context, session string, file io.Reader, filename string, opts nova.UploadOptions) (int, error) {
+	return breaker.Call(c.breaker, func() (int, error) {
+		return c.inner.Upload(ctx, session, file, filename, opts)
+	}, novaOutcome)
+}
+
+func (c *BreakerNovaClient) URLUpload(ctx context.Context, session, imageURL string, opts nova.UploadOptions) (int, error) {
+	return breaker.Call(c.breaker, func() (int, error) {
+		return c.inner.URLUpload(ctx, session, imageURL, opts)
+	}, novaOutcome)
+}
+
+func (c *BreakerNovaClient) GetSubmission(ctx context.Context, subID int) (*nova.Submission, error) {
+	return breaker.Call(c.breaker, func() (*nova.Submission, error) {
+		return c.inner.GetSubmission(ctx, subID)
+	}, novaOutcome)
+}
+
+func (c *BreakerNovaClient) GetJobStatus(ctx context.Context, jobID int) (string, error) {
+	return breaker.Call(c.breaker, func() (string, error) {
+		return c.inner.GetJobStatus(ctx, jobID)
+	}, novaOutcome)
+}
+
+func (c *BreakerNovaClient) GetJobInfo(ctx context.Context, jobID int) (*nova.JobInfo, error) {
+	return breaker.Call(c.breaker, func() (*nova.JobInfo, error) {
+		return c.inner.GetJobInfo(ctx, jobID)
+	}, novaOutcome)
+}
+
+func (c *BreakerNovaClient) GetAnnotations(ctx context.Context, jobID int) ([]nova.Annotation, error) {
+	return breaker.Call(c.breaker, func() ([]nova.Annotation, error) {
+		return c.inner.GetAnnotations(ctx, jobID)
+	}, novaOutcome)
+}
+
+func (c *BreakerNovaClient) GetWCSFile(ctx context.Context, jobID int) ([]byte, error) {
+	return breaker.Call(c.breaker, func() ([]byte, error) {
+		return c.inner.GetWCSFile(ctx, jobID)
+	}, novaOutcome)
+}
+
+func (c *BreakerNovaClient) AnnotatedImageURL(jobID int) string {
+	return c.inner.AnnotatedImageURL(jobID)
+}
+
+// BreakerCatalogResolver fails fast with a breaker.OpenError while a
+// catalog service is unavailable. Unknown objects do not count as failures.
+type BreakerCatalogResolver struct {
+	inner   CatalogResolver
+	breaker *breaker.Breaker
+}
+
+func NewBreakerCatalogResolver(inner CatalogResolver, b *breaker.Breaker) *BreakerCatalogResolver {
+	return &BreakerCatalogResolver{inner: inner, breaker: b}
+}
+
+func catalogOutcome(err error) error {
+	if errors.Is(err, catalog.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (c *BreakerCatalogResolver) QueryObjects(ctx context.Context, identifiers []string) (map[string]*catalog.Object, error) {
+	return breaker.Call(c.breaker, func() (map[string]*catalog.Object, error) {
+		return c.inner.QueryObjects(ctx, identifiers)
+	}, catalogOutcome)
+}
+
+func (c *BreakerCatalogResolver) ConeSearch(ctx context.Context, q catalog.ConeQuery) ([]*catalog.Object, error) {
+	return breaker.Call(c.breaker, func() ([]*catalog.Object, error) {
+		return c.inner.ConeSearch(ctx, q)
+	}, catalogOutcome)
+}
+
+// BreakerKVClient fails fast with a breaker.OpenError while Cloudflare KV
+// is unavailable.
+type BreakerKVClient struct {
+	inner   kv.Client
+	breaker *breaker.Breaker
+}
+
+func NewBreakerKVClient(inner kv.Client, b *breaker.Breaker) *BreakerKVClient {
+	return &BreakerKVClient{inner: inner, breaker: b}
+}
+
+type kvValue struct {
+	data  []byte
+	found bool
+}
+
+func (c *BreakerKVClient) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := breaker.Call(c.breaker, func() (kvValue, error) {
+		data, found, err := c.inner.Get(ctx, key)
+		return kvValue{data: data, found: found}, err
+	}, nil)
+	return v.data, v.found, err
+}
+
+func (c *BreakerKVClient) Put(ctx context.Context, key string, value []byte, ttlSeconds int) error {
+	_, err := breaker.Call(c.breaker, func() (struct{}, error) {
+		return struct{}{}, c.inner.Put(ctx, key, value, ttlSeconds)
+	}, nil)
+	return err
+}
+
+func (c *BreakerKVClient) List(ctx context.Context, prefix string) ([]string, error) {
+	return breaker.Call(c.breaker, func() ([]string, error) {
+		return c.inner.List(ctx, prefix)
+	}, nil)
+}
+
+func (c *BreakerKVClient) Delete(ctx context.Context, key string) error {
+	_, err := breaker.Call(c.breaker, func() (struct{}, error) {
+		return struct{}{}, c.inner.Delete(ctx, key)
+	}, nil)
+	return err
+}
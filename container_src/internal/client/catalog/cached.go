@@ -0,0 +1,159 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"server/internal/client/kv"
+)
+
+type resolver interface {
+	QueryObjects(ctx context.Context, identifiers []string) (map[string]*Object, error)
+	ConeSearch(ctx context.Context, q ConeQuery) ([]*Object, error)
+}
+
+// CachedResolver keeps one catalog's answers in KV under its own key
+// prefix, which should be versioned so entries cached before Object gained
+// fields are not served. Identifiers the catalog does not know are cached
+// too, for the shorter missTTL, so a name Nova keeps reporting is not
+// looked up again on every solve.
+type CachedResolver struct {
+	inner   resolver
+	kv      kv.Client
+	prefix  string
+	ttl     int
+	missTTL int
+}
+
+func NewCachedResolver(inner resolver, kvClient kv.Client, prefix string, ttlSeconds, missTTLSeconds int) *CachedResolver {
+	return &CachedResolver{
+		inner:   inner,
+		kv:      kvClient,
+		prefix:  prefix,
+		ttl:     ttlSeconds,
+		missTTL: missTTLSeconds,
+	}
+}
+
+// QueryObjects answers what it can from the cache and resolves only the
+// identifiers it has no entry for with one batched query. An entry of
+// null records that the catalog did not know the identifier.
+func (c *CachedResolver) QueryObjects(ctx context.Context, identifiers []string) (map[string]*Object, error) {
+	found := make(map[string]*Object, len(identifiers))
+	var mu sync.Mutex
+	var misses []string
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(10)
+	for _, id := range identifiers {
+		g.Go(func() error {
+			var obj *Object
+			ok := c.cached(gctx, c.objectKey(id), &obj)
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				if obj != nil {
+					found[id] = obj
+				}
+			} else {
+				misses = append(misses, id)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	if len(misses) == 0 {
+		return found, nil
+	}
+
+	resolved, err := c.inner.QueryObjects(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range misses {
+		obj, ok := resolved[id]
+		if !ok {
+			go c.cacheResult(c.objectKey(id), nil, c.missTTL)
+			continue
+		}
+		found[id] = obj
+		go c.cacheResult(c.objectKey(id), obj, c.ttl)
+	}
+	return found, nil
+}
+
+// ConeSearch caches results by the exact query; callers quantise cones so
+// that nearby requests share entries.
+func (c *CachedResolver) ConeSearch(ctx context.Context, q ConeQuery) ([]*Object, error) {
+	key := c.coneKey(q)
+
+	var objects []*Object
+	if c.cached(ctx, key, &objects) {
+		return objects, nil
+	}
+
+	objects, err := c.inner.ConeSearch(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	go c.cacheResult(key, objects, c.ttl)
+
+	return objects, nil
+}
+
+func (c *CachedResolver) cached(ctx context.Context, key string, v any) bool {
+	data, found, err := c.kv.Get(ctx, key)
+	if err != nil {
+		log.Printf("cache get error for %q: %v", key, err)
+		return false
+	}
+	if !found {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Printf("cache unmarshal error for %q: %v", key, err)
+		return false
+	}
+	return true
+}
+
+func (c *CachedResolver) cacheResult(key string, v any, ttlSeconds int) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("cache marshal error for %q: %v", key, err)
+		return
+	}
+
+	if err := c.kv.Put(context.Background(), key, data, ttlSeconds); err != nil {
+		log.Printf("cache put error for %q: %v", key, err)
+	}
+}
+
+func (c *CachedResolver) objectKey(identifier string) string {
+	normalized := strings.ToLower(identifier)
+	normalized = strings.ReplaceAll(normalized, " ", "")
+	return c.prefix + normalized
+}
+
+func (c *CachedResolver) coneKey(q ConeQuery) string {
+	key := fmt.Sprintf("%scone:%s:%s:%s:%d", c.prefix, FormatFloat(q.RA), FormatFloat(q.Dec), FormatFloat(q.Radius), q.Limit)
+	if q.MaxMagnitude != nil {
+		key += ":" + FormatFloat(*q.MaxMagnitude)
+	}
+	if q.Types != nil {
+		op := "in"
+		if q.Types.Exclude {
+			op = "not"
+		}
+		key += ":" + op + "=" + strings.Join(q.Types.Codes, ",")
+	}
+	return key
+}
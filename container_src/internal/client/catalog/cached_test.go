@@ -0,0 +1,127 @@
+package catalog_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"server/internal/client/catalog"
+)
+
+// memoryKV is a kv.Client that records the TTL of every entry and signals
+// each write, since the resolver caches in the background.
+type memoryKV struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	ttls    map[string]int
+	puts    chan string
+}
+
+func newMemoryKV() *memoryKV {
+	return &memoryKV{entries: map[string][]byte{}, ttls: map[string]int{}, puts: make(chan string, 16)}
+}
+
+func (m *memoryKV) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.entries[key]
+	return data, ok, nil
+}
+
+func (m *memoryKV) Put(_ context.Context, key string, value []byte, ttlSeconds int) error {
+	m.mu.Lock()
+	m.entries[key], m.ttls[key] = value, ttlSeconds
+	m.mu.Unlock()
+	m.puts <- key
+	return nil
+}
+
+func (m *memoryKV) List(_ context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *memoryKV) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	delete(m.ttls, key)
+	return nil
+}
+
+func (m *memoryKV) waitPuts(t *testing.T, n int) {
+	t.Helper()
+	for range n {
+		select {
+		case <-m.puts:
+		case <-time.After(5 * time.Second):
+			t.Fatal("cache entry not written")
+		}
+	}
+}
+
+// stubCatalog knows a fixed set of objects, answers every cone search
+// with cone, or fails with err, and records what it is asked.
+type stubCatalog struct {
+	objects map[string]*catalog.Object
+	cone    []*catalog.Object
+	err     error
+	asked   [][]string
+}
+
+func (s *stubCatalog) QueryObjects(_ context.Context, identifiers []string) (map[string]*catalog.Object, error) {
+	s.asked = append(s.asked, slices.Sorted(slices.Values(identifiers)))
+	if s.err != nil {
+		return nil, s.err
+	}
+	found := make(map[string]*catalog.Object)
+	for _, id := range identifiers {
+		if obj, ok := s.objects[id]; ok {
+			found[id] = obj
+		}
+	}
+	return found, nil
+}
+
+func (s *stubCatalog) ConeSearch(context.Context, catalog.ConeQuery) ([]*catalog.Object, error) {
+	return s.cone, s.err
+}
+
+func TestCachedResolverCachesMisses(t *testing.T) {
+	inner := &stubCatalog{objects: map[string]*catalog.Object{"M 42": {Identifier: "M 42", ObjectType: "HII"}}}
+	kv := newMemoryKV()
+	c := catalog.NewCachedResolver(inner, kv, "test:", 3000, 60)
+
+	found, err := c.QueryObjects(context.Background(), []string{"M 42", "No Such Object"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found["M 42"] == nil {
+		t.Fatalf("found = %v", found)
+	}
+	kv.waitPuts(t, 2)
+	if kv.ttls["test:m42"] != 3000 || kv.ttls["test:nosuchobject"] != 60 {
+		t.Errorf("TTLs = %v, want 3000 for the object and 60 for the miss", kv.ttls)
+	}
+
+	found, err = c.QueryObjects(context.Background(), []string{"M 42", "No Such Object", "NGC 1976"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found["M 42"] == nil || found["M 42"].ObjectType != "HII" {
+		t.Errorf("cached found = %v", found)
+	}
+	if want := [][]string{{"M 42", "No Such Object"}, {"NGC 1976"}}; !slices.EqualFunc(inner.asked, want, slices.Equal) {
+		t.Errorf("catalog asked for %v, want %v", inner.asked, want)
+	}
+}
@@ -0,0 +1,190 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// Keys of Object.Sources. Magnitudes are tagged per band under
+// MagnitudeField.
+const (
+	FieldIdentifier     = "identifier"
+	FieldObjectType     = "objectType"
+	FieldSpectralType   = "spectralType"
+	FieldPosition       = "position"
+	FieldParallax       = "parallax"
+	FieldParallaxError  = "parallaxError"
+	FieldProperMotion   = "properMotion"
+	FieldRadialVelocity = "radialVelocity"
+	FieldRedshift       = "redshift"
+	FieldSize           = "size"
+	FieldIdentifiers    = "identifiers"
+)
+
+// MagnitudeField is the Sources key of one band's magnitude.
+func MagnitudeField(band string) string {
+	return "magnitude." + band
+}
+
+// Backend is one named catalog in a Chain.
+type Backend struct {
+	Name     string
+	Resolver resolver
+}
+
+// Chain resolves objects through its backends in order. Each backend is
+// asked only for the identifiers earlier ones did not find or left
+// incomplete, and its answers fill the fields still missing, so every
+// field of a result comes from the first backend that had it. A backend
+// that fails is skipped; the chain fails only when all of them do.
+type Chain struct {
+	backends []Backend
+}
+
+func NewChain(backends ...Backend) *Chain {
+	return &Chain{backends: backends}
+}
+
+func (c *Chain) QueryObjects(ctx context.Context, identifiers []string) (map[string]*Object, error) {
+	found := make(map[string]*Object, len(identifiers))
+	pending := identifiers
+	answered := false
+	var errs []error
+	for _, b := range c.backends {
+		if len(pending) == 0 {
+			break
+		}
+		objects, err := b.Resolver.QueryObjects(ctx, pending)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("catalog %s: %v", b.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+			continue
+		}
+		answered = true
+
+		for id, obj := range objects {
+			merged, ok := found[id]
+			if !ok {
+				merged = &Object{}
+				found[id] = merged
+			}
+			merge(merged, obj, b.Name)
+		}
+		pending = slices.DeleteFunc(slices.Clone(pending), func(id string) bool {
+			obj, ok := found[id]
+			return ok && obj.complete()
+		})
+	}
+	if !answered && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return found, nil
+}
+
+// ConeSearch answers from the first backend that finds anything, so an
+// empty answer from one catalog falls through to the next. Results of
+// different catalogs are not cross-matched, so they are not merged.
+func (c *Chain) ConeSearch(ctx context.Context, q ConeQuery) ([]*Object, error) {
+	var errs []error
+	answered := false
+	for _, b := range c.backends {
+		objects, err := b.Resolver.ConeSearch(ctx, q)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("catalog %s: %v", b.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+			continue
+		}
+		answered = true
+		if len(objects) == 0 {
+			continue
+		}
+
+		tagged := make([]*Object, len(objects))
+		for i, obj := range objects {
+			tagged[i] = &Object{}
+			merge(tagged[i], obj, b.Name)
+		}
+		return tagged, nil
+	}
+	if answered {
+		return nil, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// complete reports whether obj has what classifying and placing it needs,
+// so later backends need not be asked.
+func (o *Object) complete() bool {
+	return o.ObjectType != "" && o.RA != nil && o.Dec != nil && o.VMagnitude != nil
+}
+
+// merge fills dst's missing fields from src, tagging each with source.
+// src is not modified, since it may be shared with a cache.
+func merge(dst, src *Object, source string) {
+	if dst.Sources == nil {
+		dst.Sources = make(map[string]string)
+	}
+	take := func(field string, missing, available bool) bool {
+		if missing && available {
+			dst.Sources[field] = source
+			return true
+		}
+		return false
+	}
+
+	if take(FieldIdentifier, dst.Identifier == "", src.Identifier != "") {
+		dst.Identifier = src.Identifier
+	}
+	if take(FieldObjectType, dst.ObjectType == "", src.ObjectType != "") {
+		dst.ObjectType = src.ObjectType
+	}
+	if take(FieldSpectralType, dst.SpectralType == "", src.SpectralType != "") {
+		dst.SpectralType = src.SpectralType
+	}
+	if take(FieldPosition, dst.RA == nil || dst.Dec == nil, src.RA != nil && src.Dec != nil) {
+		dst.RA, dst.Dec = src.RA, src.Dec
+	}
+	if take(FieldParallax, dst.Parallax == nil, src.Parallax != nil) {
+		dst.Parallax = src.Parallax
+		// An error only makes sense with the parallax it belongs to.
+		dst.ParallaxError = src.ParallaxError
+		if src.ParallaxError != nil {
+			dst.Sources[FieldParallaxError] = source
+		}
+	}
+	if take(FieldProperMotion, dst.PMRA == nil || dst.PMDec == nil, src.PMRA != nil && src.PMDec != nil) {
+		dst.PMRA, dst.PMDec = src.PMRA, src.PMDec
+	}
+	if take(FieldRadialVelocity, dst.RadialVelocity == nil, src.RadialVelocity != nil) {
+		dst.RadialVelocity = src.RadialVelocity
+	}
+	if take(FieldRedshift, dst.Redshift == nil, src.Redshift != nil) {
+		dst.Redshift = src.Redshift
+	}
+	if take(FieldSize, dst.MajorAxis == nil && dst.MinorAxis == nil, src.MajorAxis != nil || src.MinorAxis != nil) {
+		dst.MajorAxis, dst.MinorAxis, dst.PositionAngle = src.MajorAxis, src.MinorAxis, src.PositionAngle
+	}
+	for band, m := range src.Magnitudes {
+		if _, ok := dst.Magnitudes[band]; !ok {
+			dst.SetMagnitude(band, m)
+			dst.Sources[MagnitudeField(band)] = source
+		}
+	}
+	for _, id := range src.Identifiers {
+		if !slices.Contains(dst.Identifiers, id) {
+			if len(dst.Identifiers) == 0 {
+				dst.Sources[FieldIdentifiers] = source
+			}
+			dst.Identifiers = append(dst.Identifiers, id)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package catalog_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"server/internal/client/catalog"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestChainMergesFieldsBySource(t *testing.T) {
+	simbad := &stubCatalog{objects: map[string]*catalog.Object{
+		// Complete, so later catalogs are not asked for it.
+		"Rigel": {Identifier: "* bet Ori", ObjectType: "s*b", RA: ptr(78.63), Dec: ptr(-8.20), VMagnitude: ptr(0.13),
+			Magnitudes: map[string]float64{"V": 0.13}, Identifiers: []string{"* bet Ori", "Rigel"}},
+		// Known, but without photometry.
+		"HIP 27989": {Identifier: "* alf Ori", ObjectType: "s*r", RA: ptr(88.79), Dec: ptr(7.41),
+			Identifiers: []string{"* alf Ori", "Betelgeuse"}},
+	}}
+	failing := &stubCatalog{err: errors.New("unavailable")}
+	vizier := &stubCatalog{objects: map[string]*catalog.Object{
+		"HIP 27989": {Identifier: "HIP 27989", ObjectType: "*", SpectralType: "M2Ib", RA: ptr(88.7929), Dec: ptr(7.4070),
+			VMagnitude: ptr(0.45), Magnitudes: map[string]float64{"V": 0.45, "B": 1.95},
+			Parallax: ptr(7.63), ParallaxError: ptr(1.64), Identifiers: []string{"HIP 27989"}},
+		"NGC 1976": {Identifier: "NGC 1976", ObjectType: "GNe", RA: ptr(83.825), Dec: ptr(-5.4), MajorAxis: ptr(66),
+			Identifiers: []string{"NGC 1976"}},
+	}}
+	chain := catalog.NewChain(
+		catalog.Backend{Name: "simbad", Resolver: simbad},
+		catalog.Backend{Name: "local", Resolver: failing},
+		catalog.Backend{Name: "vizier", Resolver: vizier},
+	)
+
+	found, err := chain.QueryObjects(context.Background(), []string{"Rigel", "HIP 27989", "NGC 1976", "Vega"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"HIP 27989", "NGC 1976", "Vega"}}; !slices.EqualFunc(vizier.asked, want, slices.Equal) {
+		t.Errorf("vizier asked for %v, want %v", vizier.asked, want)
+	}
+	if _, ok := found["Vega"]; ok || len(found) != 3 {
+		t.Errorf("found %v", slices.Sorted(maps.Keys(found)))
+	}
+
+	tests := []struct {
+		id      string
+		sources map[string]string
+	}{
+		{"Rigel", map[string]string{
+			catalog.FieldIdentifier:     "simbad",
+			catalog.FieldObjectType:     "simbad",
+			catalog.FieldPosition:       "simbad",
+			catalog.MagnitudeField("V"): "simbad",
+			catalog.FieldIdentifiers:    "simbad",
+		}},
+		{"HIP 27989", map[string]string{
+			catalog.FieldIdentifier:     "simbad",
+			catalog.FieldObjectType:     "simbad",
+			catalog.FieldPosition:       "simbad",
+			catalog.FieldIdentifiers:    "simbad",
+			catalog.FieldSpectralType:   "vizier",
+			catalog.FieldParallax:       "vizier",
+			catalog.FieldParallaxError:  "vizier",
+			catalog.MagnitudeField("V"): "vizier",
+			catalog.MagnitudeField("B"): "vizier",
+		}},
+		{"NGC 1976", map[string]string{
+			catalog.FieldIdentifier:  "vizier",
+			catalog.FieldObjectType:  "vizier",
+			catalog.FieldPosition:    "vizier",
+			catalog.FieldSize:        "vizier",
+			catalog.FieldIdentifiers: "vizier",
+		}},
+	}
+	for _, tt := range tests {
+		if got := found[tt.id].Sources; !maps.Equal(got, tt.sources) {
+			t.Errorf("%s sources = %v, want %v", tt.id, got, tt.sources)
+		}
+	}
+
+	betelgeuse := found["HIP 27989"]
+	if betelgeuse.Identifier != "* alf Ori" || betelgeuse.ObjectType != "s*r" || *betelgeuse.RA != 88.79 {
+		t.Errorf("fields SIMBAD had were replaced: %+v", betelgeuse)
+	}
+	if betelgeuse.VMagnitude == nil || *betelgeuse.VMagnitude != 0.45 || betelgeuse.SpectralType != "M2Ib" {
+		t.Errorf("fields SIMBAD lacked were not filled: %+v", betelgeuse)
+	}
+	if want := []string{"* alf Ori", "Betelgeuse", "HIP 27989"}; !slices.Equal(betelgeuse.Identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", betelgeuse.Identifiers, want)
+	}
+	if simbad.objects["HIP 27989"].Sources != nil || simbad.objects["HIP 27989"].VMagnitude != nil {
+		t.Error("backend's object modified")
+	}
+}
+
+func TestChainFailsOnlyWhenAllBackendsFail(t *testing.T) {
+	failing := catalog.NewChain(
+		catalog.Backend{Name: "simbad", Resolver: &stubCatalog{err: errors.New("unavailable")}},
+		catalog.Backend{Name: "vizier", Resolver: &stubCatalog{err: errors.New("unavailable")}},
+	)
+	if _, err := failing.QueryObjects(context.Background(), []string{"M 42"}); err == nil {
+		t.Error("QueryObjects succeeded with every backend failing")
+	}
+	if _, err := failing.ConeSearch(context.Background(), catalog.ConeQuery{}); err == nil {
+		t.Error("ConeSearch succeeded with every backend failing")
+	}
+}
+
+func TestChainConeSearchFallsThroughEmptyAnswers(t *testing.T) {
+	chain := catalog.NewChain(
+		catalog.Backend{Name: "simbad", Resolver: &stubCatalog{err: errors.New("unavailable")}},
+		catalog.Backend{Name: "vizier", Resolver: &stubCatalog{}},
+		catalog.Backend{Name: "local", Resolver: &stubCatalog{cone: []*catalog.Object{{Identifier: "M 42", RA: ptr(83.82), Dec: ptr(-5.39)}}}},
+	)
+	objects, err := chain.ConeSearch(context.Background(), catalog.ConeQuery{RA: 83.8, Dec: -5.4, Radius: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Sources[catalog.FieldPosition] != "local" {
+		t.Errorf("objects = %+v, want M 42 from local", objects)
+	}
+
+	empty := catalog.NewChain(
+		catalog.Backend{Name: "simbad", Resolver: &stubCatalog{err: errors.New("unavailable")}},
+		catalog.Backend{Name: "vizier", Resolver: &stubCatalog{}},
+	)
+	if objects, err := empty.ConeSearch(context.Background(), catalog.ConeQuery{}); err != nil || len(objects) != 0 {
+		t.Errorf("ConeSearch = %v, %v, want nothing found", objects, err)
+	}
+}
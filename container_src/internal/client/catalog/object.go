@@ -0,0 +1,117 @@
+// Package catalog holds what the astronomical catalog clients have in
+// common: the object record they return, a KV cache in front of any of
+// them, and the Chain that merges several into one resolver.
+package catalog
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// ErrNotFound is returned when a catalog has no object by the given name.
+var ErrNotFound = errors.New("not found")
+
+// Object is one catalog object. Magnitudes are keyed by band (U, B, V, R,
+// I, J, H, K); VMagnitude repeats the V band. Parallaxes and proper
+// motions are in mas and mas/yr, radial velocity in km/s, and galaxy
+// dimensions in arcmin with the position angle in degrees east of north.
+//
+// Sources names the catalog each field came from, keyed by the Field*
+// constants; it is set by Chain.
+type Object struct {
+	Identifier     string
+	ObjectType     string
+	SpectralType   string
+	VMagnitude     *float64
+	Magnitudes     map[string]float64
+	Parallax       *float64
+	ParallaxError  *float64
+	PMRA           *float64
+	PMDec          *float64
+	RadialVelocity *float64
+	Redshift       *float64
+	MajorAxis      *float64
+	MinorAxis      *float64
+	PositionAngle  *float64
+	RA             *float64
+	Dec            *float64
+	Identifiers    []string
+	Sources        map[string]string
+}
+
+func (o *Object) DistanceParsecs() *float64 {
+	if o.Parallax == nil || *o.Parallax <= 0 {
+		return nil
+	}
+	d := 1000.0 / *o.Parallax
+	return &d
+}
+
+// DistanceErrorParsecs propagates the parallax error to the distance.
+func (o *Object) DistanceErrorParsecs() *float64 {
+	if o.Parallax == nil || o.ParallaxError == nil || *o.Parallax <= 0 {
+		return nil
+	}
+	e := 1000.0 * *o.ParallaxError / (*o.Parallax * *o.Parallax)
+	return &e
+}
+
+// ColorIndex returns the difference between two bands' magnitudes, such
+// as B−V, when both are known.
+func (o *Object) ColorIndex(from, to string) *float64 {
+	a, ok := o.Magnitudes[from]
+	if !ok {
+		return nil
+	}
+	b, ok := o.Magnitudes[to]
+	if !ok {
+		return nil
+	}
+	c := a - b
+	return &c
+}
+
+// SetMagnitude records a band's magnitude, keeping VMagnitude in step.
+func (o *Object) SetMagnitude(band string, m float64) {
+	if o.Magnitudes == nil {
+		o.Magnitudes = make(map[string]float64)
+	}
+	o.Magnitudes[band] = m
+	if band == "V" {
+		o.VMagnitude = &m
+	}
+}
+
+// ConeQuery selects up to Limit objects within Radius degrees of RA/Dec
+// (J2000), brightest in V first. With MaxMagnitude set, objects fainter
+// than it or without a V magnitude are left out; with Types set, so are
+// objects whose type it does not match. Both apply before Limit.
+type ConeQuery struct {
+	RA           float64
+	Dec          float64
+	Radius       float64
+	MaxMagnitude *float64
+	Types        *TypeFilter
+	Limit        int
+}
+
+// TypeFilter selects objects by their SIMBAD object type code, compared
+// without regard to case. Codes are the types to keep, or with Exclude
+// the types to leave out.
+type TypeFilter struct {
+	Codes   []string
+	Exclude bool
+}
+
+// Match reports whether an object of type otype passes the filter. A nil
+// filter passes everything.
+func (f *TypeFilter) Match(otype string) bool {
+	if f == nil {
+		return true
+	}
+	listed := slices.ContainsFunc(f.Codes, func(code string) bool {
+		return strings.EqualFold(code, otype)
+	})
+	return listed != f.Exclude
+}
@@ -0,0 +1,66 @@
+package catalog
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// TAPParams are the parameters of a synchronous ADQL query answered as
+// JSON, as SIMBAD and VizieR accept them.
+func TAPParams(query string) url.Values {
+	return url.Values{
+		"request": {"doQuery"},
+		"lang":    {"adql"},
+		"format":  {"json"},
+		"query":   {query},
+	}
+}
+
+// TAPResponse is the JSON result of a TAP query.
+type TAPResponse struct {
+	Metadata []struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Data [][]any `json:"data"`
+}
+
+// Columns maps each lower-cased result column name to its index in a row.
+func (r *TAPResponse) Columns() TAPColumns {
+	columns := make(TAPColumns, len(r.Metadata))
+	for i, m := range r.Metadata {
+		columns[strings.ToLower(m.Name)] = i
+	}
+	return columns
+}
+
+// TAPColumns reads a row's values by column name.
+type TAPColumns map[string]int
+
+func (c TAPColumns) String(row []any, name string) string {
+	if idx, ok := c[name]; ok && idx < len(row) {
+		if v, ok := row[idx].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func (c TAPColumns) Float(row []any, name string) *float64 {
+	if idx, ok := c[name]; ok && idx < len(row) {
+		if v, ok := row[idx].(float64); ok {
+			return &v
+		}
+	}
+	return nil
+}
+
+// FormatFloat writes v for an ADQL statement.
+func FormatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Escape quotes s for an ADQL string literal.
+func Escape(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
@@ -4,22 +4,32 @@ import (
 	"context"
 	"io"
 
+	"server/internal/client/catalog"
 	"server/internal/client/nova"
-	"server/internal/client/simbad"
 )
 
 // NovaClient defines the contract for Nova API operations.
 type NovaClient interface {
 	Login(ctx context.Context, apiKey string) (string, error)
-	Upload(ctx context.Context, session string, file io.Reader, filename string) (int, error)
+	Upload(ctx context.Context, session string, file io.Reader, filename string, opts nova.UploadOptions) (int, error)
+	URLUpload(ctx context.Context, session, imageURL string, opts nova.UploadOptions) (int, error)
 	GetSubmission(ctx context.Context, subID int) (*nova.Submission, error)
 	GetJobStatus(ctx context.Context, jobID int) (string, error)
 	GetJobInfo(ctx context.Context, jobID int) (*nova.JobInfo, error)
 	GetAnnotations(ctx context.Context, jobID int) ([]nova.Annotation, error)
+	GetWCSFile(ctx context.Context, jobID int) ([]byte, error)
 	AnnotatedImageURL(jobID int) string
 }
 
-// SimbadClient defines the contract for SIMBAD queries.
-type SimbadClient interface {
-	QueryObject(ctx context.Context, identifier string) (*simbad.ObjectInfo, error)
+// NovaSessions provides a shared Nova session key.
+type NovaSessions interface {
+	Session(ctx context.Context) (string, error)
+	Invalidate(session string)
+}
+
+// CatalogResolver defines the contract for astronomical catalog lookups,
+// met by each catalog client and by the catalog.Chain that combines them.
+type CatalogResolver interface {
+	QueryObjects(ctx context.Context, identifiers []string) (map[string]*catalog.Object, error)
+	ConeSearch(ctx context.Context, q catalog.ConeQuery) ([]*catalog.Object, error)
 }
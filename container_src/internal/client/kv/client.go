@@ -3,6 +3,7 @@ package kv
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +16,8 @@ import (
 type Client interface {
 	Get(ctx context.Context, key string) ([]byte, bool, error)
 	Put(ctx context.Context, key string, value []byte, ttlSeconds int) error
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, key string) error
 }
 
 type KVClient struct {
@@ -98,3 +101,77 @@ func (c *KVClient) Put(ctx context.Context, key string, value []byte, ttlSeconds
 
 	return nil
 }
+
+// Delete removes key; deleting a key that does not exist is not an error.
+func (c *KVClient) Delete(ctx context.Context, key string) error {
+	reqURL := fmt.Sprintf("%s/accounts/%s/storage/kv/namespaces/%s/values/%s",
+		c.baseURL, c.accountID, c.namespaceID, url.PathEscape(key))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("kv delete: create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("kv delete: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("kv delete: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// List returns the names of all keys starting with prefix, following the
+// API's cursor across pages.
+func (c *KVClient) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	cursor := ""
+	for {
+		params := url.Values{"prefix": {prefix}, "limit": {"1000"}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		reqURL := fmt.Sprintf("%s/accounts/%s/storage/kv/namespaces/%s/keys?%s",
+			c.baseURL, c.accountID, c.namespaceID, params.Encode())
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("kv list: create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("kv list: %w", err)
+		}
+		var page struct {
+			Result []struct {
+				Name string `json:"name"`
+			} `json:"result"`
+			ResultInfo struct {
+				Cursor string `json:"cursor"`
+			} `json:"result_info"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return nil, fmt.Errorf("kv list: status %d", resp.StatusCode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("kv list: decode: %w", err)
+		}
+
+		for _, k := range page.Result {
+			keys = append(keys, k.Name)
+		}
+		if page.ResultInfo.Cursor == "" {
+			return keys, nil
+		}
+		cursor = page.ResultInfo.Cursor
+	}
+}
@@ -0,0 +1,100 @@
+// Package local resolves objects from a catalogue kept in a JSON file, for
+// deployments that want a last resort that needs no network.
+package local
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"server/internal/client/catalog"
+	"server/internal/wcs"
+)
+
+type Catalog struct {
+	objects []*catalog.Object
+	byName  map[string]*catalog.Object
+}
+
+// Load reads a JSON array of catalog.Object, as in
+// [{"Identifier": "M 42", "ObjectType": "HII", "RA": 83.82, "Dec": -5.39}].
+// A V band in Magnitudes stands in for VMagnitude.
+func Load(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read local catalog: %w", err)
+	}
+	var objects []*catalog.Object
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("parse local catalog %s: %w", path, err)
+	}
+	return NewCatalog(objects), nil
+}
+
+func NewCatalog(objects []*catalog.Object) *Catalog {
+	c := &Catalog{
+		objects: objects,
+		byName:  make(map[string]*catalog.Object),
+	}
+	for _, obj := range objects {
+		if v, ok := obj.Magnitudes["V"]; ok && obj.VMagnitude == nil {
+			obj.SetMagnitude("V", v)
+		}
+		for _, name := range append([]string{obj.Identifier}, obj.Identifiers...) {
+			if _, ok := c.byName[key(name)]; !ok && name != "" {
+				c.byName[key(name)] = obj
+			}
+		}
+	}
+	return c
+}
+
+func (c *Catalog) QueryObjects(_ context.Context, identifiers []string) (map[string]*catalog.Object, error) {
+	found := make(map[string]*catalog.Object)
+	for _, id := range identifiers {
+		if obj, ok := c.byName[key(id)]; ok {
+			found[id] = obj
+		}
+	}
+	return found, nil
+}
+
+func (c *Catalog) ConeSearch(_ context.Context, q catalog.ConeQuery) ([]*catalog.Object, error) {
+	var objects []*catalog.Object
+	for _, obj := range c.objects {
+		if obj.RA == nil || obj.Dec == nil || wcs.Separation(q.RA, q.Dec, *obj.RA, *obj.Dec) > q.Radius {
+			continue
+		}
+		if q.MaxMagnitude != nil && (obj.VMagnitude == nil || *obj.VMagnitude > *q.MaxMagnitude) {
+			continue
+		}
+		if !q.Types.Match(obj.ObjectType) {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+
+	slices.SortStableFunc(objects, func(a, b *catalog.Object) int {
+		switch {
+		case a.VMagnitude != nil && b.VMagnitude != nil:
+			return cmp.Compare(*a.VMagnitude, *b.VMagnitude)
+		case a.VMagnitude != nil:
+			return -1
+		case b.VMagnitude != nil:
+			return 1
+		}
+		return 0
+	})
+	if q.Limit > 0 && len(objects) > q.Limit {
+		objects = objects[:q.Limit]
+	}
+	return objects, nil
+}
+
+func key(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
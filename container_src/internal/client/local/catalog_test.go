@@ -0,0 +1,76 @@
+package local_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"server/internal/client/catalog"
+	"server/internal/client/local"
+)
+
+func load(t *testing.T) *local.Catalog {
+	t.Helper()
+	c, err := local.Load("testdata/catalog.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestQueryObjects(t *testing.T) {
+	found, err := load(t).QueryObjects(context.Background(), []string{"M42", "ngc 1976", "Orion  Nebula", "RIGEL", "HIP 27989", "Vega"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 5 {
+		t.Errorf("found %d objects, want 5: %v", len(found), found)
+	}
+	for _, id := range []string{"M42", "ngc 1976", "Orion  Nebula"} {
+		if obj := found[id]; obj == nil || obj.Identifier != "M 42" {
+			t.Errorf("%s = %+v, want M 42", id, obj)
+		}
+	}
+	if obj := found["RIGEL"]; obj == nil || obj.Identifier != "* bet Ori" {
+		t.Errorf("RIGEL = %+v", obj)
+	}
+	if obj := found["HIP 27989"]; obj == nil || obj.Identifier != "* alf Ori" {
+		t.Errorf("HIP 27989 = %+v", obj)
+	}
+	// A V band in Magnitudes stands in for VMagnitude.
+	if v := found["M42"].VMagnitude; v == nil || *v != 4.0 {
+		t.Errorf("M 42 V = %v, want 4.0", v)
+	}
+}
+
+func TestConeSearch(t *testing.T) {
+	maxMag := 5.0
+	tests := []struct {
+		name string
+		q    catalog.ConeQuery
+		want []string
+	}{
+		{"radius", catalog.ConeQuery{Radius: 6}, []string{"* bet Ori", "M 42", "IC 434"}},
+		{"small radius", catalog.ConeQuery{Radius: 1}, []string{"M 42"}},
+		{"magnitude", catalog.ConeQuery{Radius: 6, MaxMagnitude: &maxMag}, []string{"* bet Ori", "M 42"}},
+		{"types", catalog.ConeQuery{Radius: 6, Types: &catalog.TypeFilter{Codes: []string{"s*b"}, Exclude: true}}, []string{"M 42", "IC 434"}},
+		{"limit", catalog.ConeQuery{Radius: 6, Limit: 2}, []string{"* bet Ori", "M 42"}},
+	}
+	c := load(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.q.RA, tt.q.Dec = 83.8, -5.4
+			objects, err := c.ConeSearch(context.Background(), tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, obj := range objects {
+				got = append(got, obj.Identifier)
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("objects = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
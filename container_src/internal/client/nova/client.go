@@ -2,8 +2,10 @@ package nova
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"server/internal/config"
 	"server/internal/util/httputil"
@@ -16,14 +18,15 @@ type Client struct {
 
 func NewClient(cfg config.NovaConfig) *Client {
 	return &Client{
-		http:    httputil.NewClient(cfg.BaseURL, cfg.Timeout),
+		http:    httputil.NewClient(cfg.BaseURL, cfg.Timeout, httputil.RetryPolicy(cfg.Retry)),
 		baseURL: cfg.BaseURL,
 	}
 }
 
 func (c *Client) Login(ctx context.Context, apiKey string) (string, error) {
 	var r LoginResponse
-	if err := c.http.PostFormDecode(ctx, "/api/login", map[string]string{"apikey": apiKey}, nil, "", &r); err != nil {
+	// Logging in again only creates another session, so it is safe to retry.
+	if err := c.http.Idempotent().PostFormDecode(ctx, "/api/login", map[string]string{"apikey": apiKey}, nil, "", &r); err != nil {
 		return "", fmt.Errorf("login: %w", err)
 	}
 	if r.Status != "success" {
@@ -32,13 +35,26 @@ func (c *Client) Login(ctx context.Context, apiKey string) (string, error) {
 	return r.Session, nil
 }
 
-func (c *Client) Upload(ctx context.Context, session string, file io.Reader, filename string) (int, error) {
+func (c *Client) Upload(ctx context.Context, session string, file io.Reader, filename string, opts UploadOptions) (int, error) {
+	req := uploadRequest{Session: session, PubliclyVisible: "n", UploadOptions: opts}
 	var r UploadResponse
-	if err := c.http.PostFormDecode(ctx, "/api/upload", map[string]string{"session": session, "publicly_visible": "n"}, file, filename, &r); err != nil {
+	if err := c.http.PostFormDecode(ctx, "/api/upload", req, file, filename, &r); err != nil {
 		return 0, fmt.Errorf("upload: %w", err)
 	}
 	if r.Status != "success" {
-		return 0, fmt.Errorf("upload failed")
+		return 0, uploadError("upload failed", r)
+	}
+	return r.SubID, nil
+}
+
+func (c *Client) URLUpload(ctx context.Context, session, imageURL string, opts UploadOptions) (int, error) {
+	req := urlUploadRequest{Session: session, URL: imageURL, PubliclyVisible: "n", UploadOptions: opts}
+	var r UploadResponse
+	if err := c.http.PostFormDecode(ctx, "/api/url_upload", req, nil, "", &r); err != nil {
+		return 0, fmt.Errorf("url upload: %w", err)
+	}
+	if r.Status != "success" {
+		return 0, uploadError("url upload failed", r)
 	}
 	return r.SubID, nil
 }
@@ -77,6 +93,25 @@ func (c *Client) GetAnnotations(ctx context.Context, jobID int) ([]Annotation, e
 	return r.Annotations, nil
 }
 
+// GetWCSFile returns the FITS header describing the job's WCS solution.
+func (c *Client) GetWCSFile(ctx context.Context, jobID int) ([]byte, error) {
+	data, err := c.http.GetBytes(ctx, fmt.Sprintf("/wcs_file/%d", jobID), 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("wcs file: %w", err)
+	}
+	return data, nil
+}
+
 func (c *Client) AnnotatedImageURL(jobID int) string {
 	return fmt.Sprintf("%s/annotated_display/%d", c.baseURL, jobID)
 }
+
+func uploadError(prefix string, r UploadResponse) error {
+	if strings.Contains(strings.ToLower(r.ErrorMessage), "session") {
+		return fmt.Errorf("%s: %s: %w", prefix, r.ErrorMessage, ErrInvalidSession)
+	}
+	if r.ErrorMessage != "" {
+		return fmt.Errorf("%s: %s", prefix, r.ErrorMessage)
+	}
+	return errors.New(prefix)
+}
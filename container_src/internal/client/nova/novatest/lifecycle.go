@@ -0,0 +1,160 @@
+package novatest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strings"
+
+	"server/internal/client/nova"
+	"server/internal/wcs"
+)
+
+const (
+	statusSolving = "solving"
+	statusSuccess = "success"
+	statusFailure = "failure"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = statusSuccess
+	OutcomeFailure Outcome = statusFailure
+)
+
+// Lifecycle scripts how a submission progresses. The submission lists no
+// job for its first QueuedPolls polls, then the job reports "solving" for
+// SolvingPolls status polls before settling on Outcome.
+type Lifecycle struct {
+	QueuedPolls  int
+	SolvingPolls int
+	Outcome      Outcome
+	Result       Result
+}
+
+// Result is what a successful job reports. The WCS file is derived from
+// Calibration and the image size.
+type Result struct {
+	Calibration nova.Calibration
+	Objects     []string
+	Annotations []nova.Annotation
+	ImageWidth  int
+	ImageHeight int
+}
+
+type submission struct {
+	lifecycle       Lifecycle
+	submissionPolls int
+	jobPolls        int
+	jobID           int
+}
+
+// pollJob advances the job by one status poll and returns its status.
+func (s *submission) pollJob() string {
+	s.jobPolls++
+	return s.status()
+}
+
+func (s *submission) status() string {
+	if s.jobPolls <= s.lifecycle.SolvingPolls {
+		return statusSolving
+	}
+	if s.lifecycle.Outcome == OutcomeFailure {
+		return statusFailure
+	}
+	return statusSuccess
+}
+
+// DefaultLifecycle queues and solves for one poll each and then succeeds
+// with a wide field of Orion.
+func DefaultLifecycle() Lifecycle {
+	result := Result{
+		Calibration: nova.Calibration{
+			RA:          83.5,
+			Dec:         -0.5,
+			PixScale:    90,
+			Orientation: 0,
+			Parity:      1,
+		},
+		Objects:     []string{"Betelgeuse", "Rigel", "The Great Orion Nebula", "M 42", "NGC 1976"},
+		ImageWidth:  1000,
+		ImageHeight: 800,
+	}
+	c := &result.Calibration
+	c.WidthArcsec = c.PixScale * float64(result.ImageWidth)
+	c.HeightArcsec = c.PixScale * float64(result.ImageHeight)
+	c.Radius = math.Hypot(c.WidthArcsec, c.HeightArcsec) / 2 / 3600
+
+	solution, err := wcs.Parse(result.wcsHeader())
+	if err != nil {
+		panic(fmt.Sprintf("novatest: default wcs: %v", err))
+	}
+	for _, obj := range []struct {
+		name    string
+		ra, dec float64
+	}{
+		{"Betelgeuse", 88.7929, 7.4071},
+		{"Rigel", 78.6345, -8.2016},
+		{"M 42", 83.8221, -5.3911},
+	} {
+		if x, y, ok := solution.SkyToImage(obj.ra, obj.dec); ok {
+			result.Annotations = append(result.Annotations, nova.Annotation{
+				Names: []string{obj.name}, PixelX: x, PixelY: y, Type: "bright",
+			})
+		}
+	}
+
+	return Lifecycle{QueuedPolls: 1, SolvingPolls: 1, Outcome: OutcomeSuccess, Result: result}
+}
+
+// wcsHeader renders a TAN projection centred on the image with the
+// calibration's pixel scale and orientation, as Nova's wcs_file does.
+func (r Result) wcsHeader() []byte {
+	c := r.Calibration
+	scale := c.PixScale / 3600
+	theta := c.Orientation * math.Pi / 180
+	flip := 1.0
+	if c.Parity < 0 {
+		flip = -1
+	}
+	cards := []string{
+		card("SIMPLE", "T"),
+		card("BITPIX", "8"),
+		card("NAXIS", "0"),
+		card("WCSAXES", "2"),
+		card("CTYPE1", "'RA---TAN'"),
+		card("CTYPE2", "'DEC--TAN'"),
+		card("EQUINOX", "2000.0"),
+		card("CRVAL1", fmt.Sprintf("%.10g", c.RA)),
+		card("CRVAL2", fmt.Sprintf("%.10g", c.Dec)),
+		card("CRPIX1", fmt.Sprintf("%.10g", float64(r.ImageWidth+1)/2)),
+		card("CRPIX2", fmt.Sprintf("%.10g", float64(r.ImageHeight+1)/2)),
+		card("CD1_1", fmt.Sprintf("%.10g", -flip*scale*math.Cos(theta))),
+		card("CD1_2", fmt.Sprintf("%.10g", flip*scale*math.Sin(theta))),
+		card("CD2_1", fmt.Sprintf("%.10g", scale*math.Sin(theta))),
+		card("CD2_2", fmt.Sprintf("%.10g", scale*math.Cos(theta))),
+		card("IMAGEW", fmt.Sprintf("%d", r.ImageWidth)),
+		card("IMAGEH", fmt.Sprintf("%d", r.ImageHeight)),
+	}
+
+	var buf bytes.Buffer
+	for _, c := range cards {
+		buf.WriteString(c)
+	}
+	buf.WriteString(fmt.Sprintf("%-80s", "END"))
+	if pad := buf.Len() % 2880; pad != 0 {
+		buf.WriteString(strings.Repeat(" ", 2880-pad))
+	}
+	return buf.Bytes()
+}
+
+func card(key, value string) string {
+	return fmt.Sprintf("%-8s= %20s%-50s", key, value, "")
+}
+
+// placeholderPNG is a 1x1 transparent image served as the annotated
+// display.
+var placeholderPNG, _ = base64.StdEncoding.DecodeString(
+	"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII=")
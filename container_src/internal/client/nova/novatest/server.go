@@ -0,0 +1,393 @@
+// Package novatest provides an in-process fake of the Nova
+// (nova.astrometry.net) API for tests.
+//
+// A Server accepts any API key, hands out sessions and assigns each upload
+// a submission whose job follows a scripted Lifecycle, advancing one step
+// per poll. Failures and latency can be injected per endpoint:
+//
+//	srv := novatest.NewServer()
+//	defer srv.Close()
+//	srv.Enqueue(novatest.Lifecycle{QueuedPolls: 2, SolvingPolls: 3, Outcome: novatest.OutcomeFailure})
+//	srv.FailNext(novatest.EndpointJobs, novatest.Failure{Status: http.StatusServiceUnavailable})
+//	client := nova.NewClient(config.NovaConfig{BaseURL: srv.URL, Timeout: time.Second})
+package novatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"server/internal/client/nova"
+)
+
+type Endpoint string
+
+const (
+	EndpointLogin            Endpoint = "login"
+	EndpointUpload           Endpoint = "upload"
+	EndpointURLUpload        Endpoint = "url_upload"
+	EndpointSubmissions      Endpoint = "submissions"
+	EndpointJobs             Endpoint = "jobs"
+	EndpointInfo             Endpoint = "info"
+	EndpointAnnotations      Endpoint = "annotations"
+	EndpointAnnotatedDisplay Endpoint = "annotated_display"
+	EndpointWCSFile          Endpoint = "wcs_file"
+)
+
+// Failure replaces one response from an endpoint. With Status set, the
+// server answers with that HTTP status. With Message set, it answers 200
+// with Nova's {"status": "error"} body, which is how Nova reports a bad
+// session or a rejected upload. Disconnect drops the connection instead.
+type Failure struct {
+	Status     int
+	RetryAfter string
+	Message    string
+	Disconnect bool
+}
+
+// Upload is an upload the server has accepted.
+type Upload struct {
+	SubID    int
+	Filename string
+	Data     []byte
+	URL      string
+	// Options is the decoded request-json field.
+	Options map[string]any
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	sessions    map[string]bool
+	nextID      int
+	submissions map[int]*submission
+	jobs        map[int]*submission
+	uploads     []Upload
+	scripts     []Lifecycle
+	lifecycle   Lifecycle
+	failures    map[Endpoint][]Failure
+	latency     map[Endpoint]time.Duration
+	requests    map[Endpoint]int
+}
+
+// NewServer starts a server whose jobs follow DefaultLifecycle. Call Close
+// when done.
+func NewServer() *Server {
+	s := &Server{
+		sessions:    make(map[string]bool),
+		nextID:      1000,
+		submissions: make(map[int]*submission),
+		jobs:        make(map[int]*submission),
+		lifecycle:   DefaultLifecycle(),
+		failures:    make(map[Endpoint][]Failure),
+		latency:     make(map[Endpoint]time.Duration),
+		requests:    make(map[Endpoint]int),
+	}
+	s.Server = httptest.NewServer(s.routes())
+	return s
+}
+
+// SetLifecycle changes the lifecycle used for uploads with nothing
+// enqueued.
+func (s *Server) SetLifecycle(lc Lifecycle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lifecycle = lc
+}
+
+// Enqueue scripts the lifecycle of the next upload. Lifecycles are used in
+// the order they were enqueued.
+func (s *Server) Enqueue(lc Lifecycle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts = append(s.scripts, lc)
+}
+
+// FailNext makes the next request to endpoint fail as described. Failures
+// queue up, so calling it twice fails two requests.
+func (s *Server) FailNext(endpoint Endpoint, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[endpoint] = append(s.failures[endpoint], f)
+}
+
+// SetLatency delays every response from endpoint by d.
+func (s *Server) SetLatency(endpoint Endpoint, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency[endpoint] = d
+}
+
+// ExpireSessions invalidates every session handed out so far, as Nova
+// does after a while.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.sessions)
+}
+
+// Requests returns how many requests endpoint has received, including
+// failed ones.
+func (s *Server) Requests(endpoint Endpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+// Uploads returns the uploads accepted so far.
+func (s *Server) Uploads() []Upload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Upload(nil), s.uploads...)
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/login", s.handle(EndpointLogin, s.login))
+	mux.HandleFunc("POST /api/upload", s.handle(EndpointUpload, s.upload))
+	mux.HandleFunc("POST /api/url_upload", s.handle(EndpointURLUpload, s.upload))
+	mux.HandleFunc("GET /api/submissions/{id}", s.handle(EndpointSubmissions, s.submission))
+	mux.HandleFunc("GET /api/jobs/{id}", s.handle(EndpointJobs, s.jobStatus))
+	mux.HandleFunc("GET /api/jobs/{id}/info/", s.handle(EndpointInfo, s.jobInfo))
+	mux.HandleFunc("GET /api/jobs/{id}/annotations/", s.handle(EndpointAnnotations, s.annotations))
+	mux.HandleFunc("GET /annotated_display/{id}", s.handle(EndpointAnnotatedDisplay, s.annotatedDisplay))
+	mux.HandleFunc("GET /wcs_file/{id}", s.handle(EndpointWCSFile, s.wcsFile))
+	return mux
+}
+
+// handle counts the request, applies latency and any queued failure, and
+// otherwise calls next.
+func (s *Server) handle(endpoint Endpoint, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[endpoint]++
+		delay := s.latency[endpoint]
+		var failure *Failure
+		if queued := s.failures[endpoint]; len(queued) > 0 {
+			failure = &queued[0]
+			s.failures[endpoint] = queued[1:]
+		}
+		s.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if failure == nil {
+			next(w, r)
+			return
+		}
+		fail(w, *failure)
+	}
+}
+
+func fail(w http.ResponseWriter, f Failure) {
+	switch {
+	case f.Disconnect:
+		if conn, _, err := http.NewResponseController(w).Hijack(); err == nil {
+			_ = conn.Close()
+		}
+	case f.Status != 0:
+		if f.RetryAfter != "" {
+			w.Header().Set("Retry-After", f.RetryAfter)
+		}
+		http.Error(w, http.StatusText(f.Status), f.Status)
+	default:
+		writeJSON(w, map[string]any{"status": "error", "errormessage": f.Message})
+	}
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		APIKey string `json:"apikey"`
+	}
+	if err := decodeRequestJSON(r, &req); err != nil || req.APIKey == "" {
+		writeJSON(w, map[string]any{"status": "error", "errormessage": "bad apikey"})
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	session := fmt.Sprintf("session-%d", s.nextID)
+	s.sessions[session] = true
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]any{"status": "success", "message": "authenticated user: test", "session": session})
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	var options map[string]any
+	if err := json.Unmarshal([]byte(r.FormValue("request-json")), &options); err != nil {
+		http.Error(w, "invalid request-json", http.StatusBadRequest)
+		return
+	}
+	upload := Upload{Options: options}
+	upload.URL, _ = options["url"].(string)
+
+	if file, header, err := r.FormFile("file"); err == nil {
+		upload.Filename = header.Filename
+		upload.Data, _ = io.ReadAll(file)
+		_ = file.Close()
+	} else if upload.URL == "" {
+		writeJSON(w, map[string]any{"status": "error", "errormessage": "no file or url given"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, _ := options["session"].(string); !s.sessions[session] {
+		writeJSON(w, map[string]any{"status": "error", "errormessage": "no session with key \"" + session + "\""})
+		return
+	}
+
+	lc := s.lifecycle
+	if len(s.scripts) > 0 {
+		lc, s.scripts = s.scripts[0], s.scripts[1:]
+	}
+	s.nextID++
+	upload.SubID = s.nextID
+	s.submissions[upload.SubID] = &submission{lifecycle: lc}
+	s.uploads = append(s.uploads, upload)
+
+	writeJSON(w, map[string]any{"status": "success", "subid": upload.SubID, "hash": fmt.Sprintf("%040d", upload.SubID)})
+}
+
+func (s *Server) submission(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.submissions[pathID(r)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if sub.jobID == 0 {
+		if sub.submissionPolls++; sub.submissionPolls > sub.lifecycle.QueuedPolls {
+			s.nextID++
+			sub.jobID = s.nextID
+			s.jobs[sub.jobID] = sub
+		}
+	}
+	jobs := []any{}
+	if sub.jobID != 0 {
+		jobs = append(jobs, sub.jobID)
+	}
+	writeJSON(w, map[string]any{"jobs": jobs, "processing_started": "2024-01-01 00:00:00"})
+}
+
+func (s *Server) jobStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.jobs[pathID(r)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, map[string]any{"status": sub.pollJob()})
+}
+
+// jobInfo reports the calibration and objects once the job has solved;
+// before that, Nova sends only the status.
+func (s *Server) jobInfo(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.job(w, r)
+	if !ok {
+		return
+	}
+	status := sub.status()
+	if status != statusSuccess {
+		writeJSON(w, map[string]any{"status": status, "calibration": nil, "objects_in_field": []string{}})
+		return
+	}
+	result := sub.lifecycle.Result
+	writeJSON(w, map[string]any{
+		"status":           status,
+		"calibration":      result.Calibration,
+		"objects_in_field": result.Objects,
+		"machine_tags":     result.Objects,
+	})
+}
+
+func (s *Server) annotations(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.job(w, r)
+	if !ok {
+		return
+	}
+	annotations := []nova.Annotation{}
+	if sub.status() == statusSuccess && sub.lifecycle.Result.Annotations != nil {
+		annotations = sub.lifecycle.Result.Annotations
+	}
+	writeJSON(w, map[string]any{"annotations": annotations})
+}
+
+func (s *Server) annotatedDisplay(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.job(w, r); !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	if _, err := w.Write(placeholderPNG); err != nil {
+		log.Printf("novatest: write image: %v", err)
+	}
+}
+
+func (s *Server) wcsFile(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.job(w, r)
+	if !ok {
+		return
+	}
+	if sub.status() != statusSuccess {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/fits")
+	if _, err := w.Write(sub.lifecycle.Result.wcsHeader()); err != nil {
+		log.Printf("novatest: write wcs file: %v", err)
+	}
+}
+
+// job looks up the job in the request path.
+func (s *Server) job(w http.ResponseWriter, r *http.Request) (*submission, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.jobs[pathID(r)]
+	if !ok {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	return sub, true
+}
+
+func pathID(r *http.Request) int {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return -1
+	}
+	return id
+}
+
+func decodeRequestJSON(r *http.Request, v any) error {
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(r.FormValue("request-json")), v)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("novatest: write response: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package nova
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	ErrMissingAPIKey  = errors.New("nova API key not set")
+	ErrInvalidSession = errors.New("invalid or expired session")
+)
+
+type loginer interface {
+	Login(ctx context.Context, apiKey string) (string, error)
+}
+
+// SessionManager caches a Nova session key and shares it between callers.
+// The key is refreshed in the background once it passes refreshAt, and
+// concurrent logins are collapsed into a single request.
+type SessionManager struct {
+	client loginer
+	apiKey string
+	ttl    time.Duration
+	group  singleflight.Group
+
+	mu        sync.Mutex
+	session   string
+	refreshAt time.Time
+	expiresAt time.Time
+}
+
+func NewSessionManager(client loginer, apiKey string, ttl time.Duration) *SessionManager {
+	return &SessionManager{client: client, apiKey: apiKey, ttl: ttl}
+}
+
+// Session returns a usable session key, logging in if none is cached.
+func (m *SessionManager) Session(ctx context.Context) (string, error) {
+	if m.apiKey == "" {
+		return "", ErrMissingAPIKey
+	}
+
+	m.mu.Lock()
+	session, refreshAt, expiresAt := m.session, m.refreshAt, m.expiresAt
+	m.mu.Unlock()
+
+	now := time.Now()
+	if session != "" && now.Before(expiresAt) {
+		if !now.Before(refreshAt) {
+			go func() {
+				if _, err := m.login(context.Background()); err != nil {
+					log.Printf("nova session refresh: %v", err)
+				}
+			}()
+		}
+		return session, nil
+	}
+	return m.login(ctx)
+}
+
+// Invalidate drops session if it is still the cached key, so the next
+// call to Session logs in again.
+func (m *SessionManager) Invalidate(session string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.session == session {
+		m.session = ""
+	}
+}
+
+func (m *SessionManager) login(ctx context.Context) (string, error) {
+	ch := m.group.DoChan("login", func() (any, error) {
+		session, err := m.client.Login(context.WithoutCancel(ctx), m.apiKey)
+		if err != nil {
+			return "", err
+		}
+		now := time.Now()
+		m.mu.Lock()
+		m.session = session
+		m.refreshAt = now.Add(m.ttl * 3 / 4)
+		m.expiresAt = now.Add(m.ttl)
+		m.mu.Unlock()
+		return session, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-ch:
+		if r.Err != nil {
+			return "", r.Err
+		}
+		return r.Val.(string), nil
+	}
+}
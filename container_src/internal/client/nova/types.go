@@ -6,9 +6,42 @@ type LoginResponse struct {
 	Message string `json:"message"`
 }
 
+// UploadOptions are the optional solver parameters accepted by
+// /api/upload and /api/url_upload. Nil fields are omitted from the request.
+type UploadOptions struct {
+	ScaleUnits       string   `json:"scale_units,omitempty"`
+	ScaleType        string   `json:"scale_type,omitempty"`
+	ScaleLower       *float64 `json:"scale_lower,omitempty"`
+	ScaleUpper       *float64 `json:"scale_upper,omitempty"`
+	ScaleEst         *float64 `json:"scale_est,omitempty"`
+	ScaleErr         *float64 `json:"scale_err,omitempty"`
+	CenterRA         *float64 `json:"center_ra,omitempty"`
+	CenterDec        *float64 `json:"center_dec,omitempty"`
+	Radius           *float64 `json:"radius,omitempty"`
+	DownsampleFactor *float64 `json:"downsample_factor,omitempty"`
+	TweakOrder       *int     `json:"tweak_order,omitempty"`
+	Parity           *int     `json:"parity,omitempty"`
+	UseSextractor    *bool    `json:"use_sextractor,omitempty"`
+	CrpixCenter      *bool    `json:"crpix_center,omitempty"`
+}
+
+type uploadRequest struct {
+	Session         string `json:"session"`
+	PubliclyVisible string `json:"publicly_visible"`
+	UploadOptions
+}
+
+type urlUploadRequest struct {
+	Session         string `json:"session"`
+	URL             string `json:"url"`
+	PubliclyVisible string `json:"publicly_visible"`
+	UploadOptions
+}
+
 type UploadResponse struct {
-	Status string `json:"status"`
-	SubID  int    `json:"subid"`
+	Status       string `json:"status"`
+	SubID        int    `json:"subid"`
+	ErrorMessage string `json:"errormessage"`
 }
 
 type Submission struct {
@@ -25,8 +58,14 @@ type JobInfo struct {
 }
 
 type Calibration struct {
-	RA  float64 `json:"ra"`
-	Dec float64 `json:"dec"`
+	RA           float64 `json:"ra"`
+	Dec          float64 `json:"dec"`
+	Radius       float64 `json:"radius"`
+	PixScale     float64 `json:"pixscale"`
+	Orientation  float64 `json:"orientation"`
+	Parity       float64 `json:"parity"`
+	WidthArcsec  float64 `json:"width_arcsec"`
+	HeightArcsec float64 `json:"height_arcsec"`
 }
 
 type Annotation struct {
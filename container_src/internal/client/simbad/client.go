@@ -3,14 +3,17 @@ package simbad
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"strings"
 
+	"server/internal/client/catalog"
 	"server/internal/config"
 	"server/internal/util/httputil"
 	"server/internal/util/ratelimit"
 )
 
+// ErrNotFound is returned when SIMBAD has no object by the given name.
+var ErrNotFound = catalog.ErrNotFound
+
 type Client struct {
 	http    *httputil.Client
 	limiter *ratelimit.Limiter
@@ -18,79 +21,193 @@ type Client struct {
 
 func NewClient(cfg config.SimbadConfig) *Client {
 	return &Client{
-		http:    httputil.NewClient(cfg.BaseURL, cfg.Timeout),
+		http:    httputil.NewClient(cfg.BaseURL, cfg.Timeout, httputil.RetryPolicy(cfg.Retry)),
 		limiter: ratelimit.New(5, 10),
 	}
 }
 
-func (c *Client) QueryObject(ctx context.Context, identifier string) (*ObjectInfo, error) {
+func (c *Client) QueryObject(ctx context.Context, identifier string) (*catalog.Object, error) {
 	if err := c.limiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limit: %w", err)
 	}
 
 	query := fmt.Sprintf(`
-		SELECT TOP 1 main_id, otype_txt, sp_type, flux AS vmag, plx_value, ra, dec
+		SELECT TOP 1 %s
 		FROM basic
-		LEFT JOIN flux ON basic.oid = flux.oidref AND flux.filter = 'V'
+		%s
 		WHERE main_id = '%s' OR oid IN (SELECT oidref FROM ident WHERE id = '%s')
-	`, escape(identifier), escape(identifier))
-
-	params := url.Values{
-		"request": {"doQuery"},
-		"lang":    {"adql"},
-		"format":  {"json"},
-		"query":   {query},
-	}
+	`, objectColumns, objectJoins, catalog.Escape(identifier), catalog.Escape(identifier))
 
-	var r tapResponse
-	if err := c.http.GetWithParams(ctx, "", params, &r); err != nil {
+	var r catalog.TAPResponse
+	if err := c.http.GetWithParams(ctx, "", catalog.TAPParams(query), &r); err != nil {
 		return nil, fmt.Errorf("simbad request: %w", err)
 	}
 	if len(r.Data) == 0 {
-		return nil, fmt.Errorf("not found: %s", identifier)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, identifier)
 	}
 
-	return parseRow(r.Data[0], identifier), nil
+	return parseRow(r.Columns(), r.Data[0], identifier), nil
 }
 
-type tapResponse struct {
-	Data [][]any `json:"data"`
+// maxBatchIdentifiers bounds the IN list of one QueryObjects query so the
+// request URL stays a sensible length.
+const maxBatchIdentifiers = 100
+
+// QueryObjects resolves many identifiers with one query per
+// maxBatchIdentifiers. The result maps each requested identifier to its
+// object; identifiers SIMBAD does not know are absent.
+func (c *Client) QueryObjects(ctx context.Context, identifiers []string) (map[string]*catalog.Object, error) {
+	found := make(map[string]*catalog.Object, len(identifiers))
+	for start := 0; start < len(identifiers); start += maxBatchIdentifiers {
+		batch := identifiers[start:min(start+maxBatchIdentifiers, len(identifiers))]
+		if err := c.queryBatch(ctx, batch, found); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
 }
 
-func parseRow(row []any, identifier string) *ObjectInfo {
-	info := &ObjectInfo{Identifier: identifier}
+func (c *Client) queryBatch(ctx context.Context, identifiers []string, found map[string]*catalog.Object) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("rate limit: %w", err)
+	}
 
-	getString := func(idx int) string {
-		if idx < len(row) {
-			if v, ok := row[idx].(string); ok {
-				return v
-			}
+	// SIMBAD matches ident.id exactly, but rows are mapped back loosely in
+	// case it answers in its own spacing or case.
+	requested := make(map[string][]string, len(identifiers))
+	quoted := make([]string, 0, len(identifiers))
+	for _, id := range identifiers {
+		key := matchKey(id)
+		if _, ok := requested[key]; !ok {
+			quoted = append(quoted, "'"+catalog.Escape(id)+"'")
 		}
-		return ""
+		requested[key] = append(requested[key], id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, id
+		FROM ident
+		JOIN basic ON basic.oid = ident.oidref
+		%s
+		WHERE id IN (%s)
+	`, objectColumns, objectJoins, strings.Join(quoted, ", "))
+
+	var r catalog.TAPResponse
+	if err := c.http.GetWithParams(ctx, "", catalog.TAPParams(query), &r); err != nil {
+		return fmt.Errorf("simbad request: %w", err)
 	}
 
-	getFloat := func(idx int) *float64 {
-		if idx < len(row) {
-			if v, ok := row[idx].(float64); ok {
-				return &v
+	columns := r.Columns()
+	for _, row := range r.Data {
+		matched := columns.String(row, "id")
+		for _, id := range requested[matchKey(matched)] {
+			if _, ok := found[id]; !ok {
+				found[id] = parseRow(columns, row, id)
 			}
 		}
-		return nil
+	}
+	return nil
+}
+
+// ConeSearch returns the objects in the cone, ordered by V magnitude with
+// objects lacking one last.
+func (c *Client) ConeSearch(ctx context.Context, q catalog.ConeQuery) ([]*catalog.Object, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit: %w", err)
 	}
 
-	if s := getString(0); s != "" {
+	var conditions string
+	if q.MaxMagnitude != nil {
+		conditions += " AND fv.flux <= " + catalog.FormatFloat(*q.MaxMagnitude)
+	}
+	if q.Types != nil {
+		quoted := make([]string, len(q.Types.Codes))
+		for i, code := range q.Types.Codes {
+			quoted[i] = "'" + catalog.Escape(strings.ToLower(code)) + "'"
+		}
+		op := "IN"
+		if q.Types.Exclude {
+			op = "NOT IN"
+		}
+		conditions += fmt.Sprintf(" AND LOWER(otype_txt) %s (%s)", op, strings.Join(quoted, ", "))
+	}
+	query := fmt.Sprintf(`
+		SELECT TOP %d %s
+		FROM basic
+		%s
+		WHERE CONTAINS(POINT('ICRS', ra, dec), CIRCLE('ICRS', %s, %s, %s)) = 1%s
+		ORDER BY fv.flux
+	`, q.Limit, objectColumns, objectJoins, catalog.FormatFloat(q.RA), catalog.FormatFloat(q.Dec), catalog.FormatFloat(q.Radius), conditions)
+
+	var r catalog.TAPResponse
+	if err := c.http.GetWithParams(ctx, "", catalog.TAPParams(query), &r); err != nil {
+		return nil, fmt.Errorf("simbad request: %w", err)
+	}
+
+	columns := r.Columns()
+	objects := make([]*catalog.Object, 0, len(r.Data))
+	for _, row := range r.Data {
+		objects = append(objects, parseRow(columns, row, ""))
+	}
+	return objects, nil
+}
+
+// magnitudeBands are the filters whose fluxes are fetched, each through
+// its own join on the flux table.
+var magnitudeBands = []string{"U", "B", "V", "R", "I", "J", "H", "K"}
+
+var objectColumns, objectJoins = objectQueryParts()
+
+func objectQueryParts() (string, string) {
+	columns := []string{
+		"main_id", "otype_txt", "sp_type", "plx_value", "plx_err", "pmra", "pmdec",
+		"rvz_radvel", "rvz_redshift", "galdim_majaxis", "galdim_minaxis", "galdim_angle",
+		"ra", "dec", "ids.ids",
+	}
+	joins := []string{"LEFT JOIN ids ON ids.oidref = basic.oid"}
+	for _, band := range magnitudeBands {
+		alias := "f" + strings.ToLower(band)
+		columns = append(columns, fmt.Sprintf("%s.flux AS %smag", alias, strings.ToLower(band)))
+		joins = append(joins, fmt.Sprintf("LEFT JOIN flux AS %[1]s ON %[1]s.oidref = basic.oid AND %[1]s.filter = '%[2]s'", alias, band))
+	}
+	return strings.Join(columns, ", "), strings.Join(joins, "\n\t\t")
+}
+
+func parseRow(columns catalog.TAPColumns, row []any, identifier string) *catalog.Object {
+	info := &catalog.Object{Identifier: identifier}
+
+	if s := columns.String(row, "main_id"); s != "" {
 		info.Identifier = s
 	}
-	info.ObjectType = getString(1)
-	info.SpectralType = getString(2)
-	info.VMagnitude = getFloat(3)
-	info.Parallax = getFloat(4)
-	info.RA = getFloat(5)
-	info.Dec = getFloat(6)
+	info.ObjectType = columns.String(row, "otype_txt")
+	info.SpectralType = columns.String(row, "sp_type")
+	info.Parallax = columns.Float(row, "plx_value")
+	info.ParallaxError = columns.Float(row, "plx_err")
+	info.PMRA = columns.Float(row, "pmra")
+	info.PMDec = columns.Float(row, "pmdec")
+	info.RadialVelocity = columns.Float(row, "rvz_radvel")
+	info.Redshift = columns.Float(row, "rvz_redshift")
+	info.MajorAxis = columns.Float(row, "galdim_majaxis")
+	info.MinorAxis = columns.Float(row, "galdim_minaxis")
+	info.PositionAngle = columns.Float(row, "galdim_angle")
+	info.RA = columns.Float(row, "ra")
+	info.Dec = columns.Float(row, "dec")
+
+	for _, band := range magnitudeBands {
+		if m := columns.Float(row, strings.ToLower(band)+"mag"); m != nil {
+			info.SetMagnitude(band, *m)
+		}
+	}
+
+	for _, id := range strings.Split(columns.String(row, "ids"), "|") {
+		if id = strings.TrimSpace(id); id != "" {
+			info.Identifiers = append(info.Identifiers, id)
+		}
+	}
 
 	return info
 }
 
-func escape(s string) string {
-	return strings.ReplaceAll(s, "'", "''")
+func matchKey(identifier string) string {
+	return strings.ToLower(strings.Join(strings.Fields(identifier), " "))
 }
@@ -0,0 +1,130 @@
+package simbad_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"server/internal/client/catalog"
+	"server/internal/client/simbad"
+	"server/internal/client/simbad/simbadtest"
+	"server/internal/config"
+	"server/internal/util/httputil"
+)
+
+func newClient(srv *simbadtest.Server) *simbad.Client {
+	return simbad.NewClient(config.SimbadConfig{
+		BaseURL: srv.URL,
+		Timeout: 5 * time.Second,
+		Retry:   config.RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	})
+}
+
+func TestQueryObjects(t *testing.T) {
+	srv := simbadtest.NewServer(simbadtest.DefaultObjects()...)
+	defer srv.Close()
+
+	found, err := newClient(srv).QueryObjects(context.Background(), []string{"Betelgeuse", "M 42", "NGC 1976", "No Such Object"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 3 {
+		t.Fatalf("found %d objects, want 3: %v", len(found), found)
+	}
+	if _, ok := found["No Such Object"]; ok {
+		t.Error("unknown identifier resolved")
+	}
+
+	betelgeuse := found["Betelgeuse"]
+	if betelgeuse == nil {
+		t.Fatal("Betelgeuse not found")
+	}
+	if betelgeuse.ObjectType != "s*r" || betelgeuse.SpectralType != "M1-M2Ia-Iab" {
+		t.Errorf("Betelgeuse type = %q %q", betelgeuse.ObjectType, betelgeuse.SpectralType)
+	}
+	if betelgeuse.VMagnitude == nil || *betelgeuse.VMagnitude != 0.42 {
+		t.Errorf("Betelgeuse V = %v, want 0.42", betelgeuse.VMagnitude)
+	}
+	if betelgeuse.RA == nil || betelgeuse.Dec == nil || *betelgeuse.RA != 88.79293899 || *betelgeuse.Dec != 7.40706400 {
+		t.Errorf("Betelgeuse position = %v, %v", betelgeuse.RA, betelgeuse.Dec)
+	}
+	if found["M 42"] == nil || found["NGC 1976"] == nil || found["M 42"].ObjectType != "HII" {
+		t.Errorf("M 42 = %+v, NGC 1976 = %+v", found["M 42"], found["NGC 1976"])
+	}
+	if n := len(srv.Queries()); n != 1 {
+		t.Errorf("sent %d queries, want one batch", n)
+	}
+}
+
+func TestConeSearch(t *testing.T) {
+	srv := simbadtest.NewServer(simbadtest.DefaultObjects()...)
+	defer srv.Close()
+	client := newClient(srv)
+
+	// Betelgeuse, Rigel and M 42 lie within 12 degrees of Orion's belt.
+	objects, err := client.ConeSearch(context.Background(), catalog.ConeQuery{RA: 83.8, Dec: -1, Radius: 12, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0.13, 0.42, 4.0}
+	if len(objects) != len(want) {
+		t.Fatalf("found %d objects, want %d", len(objects), len(want))
+	}
+	for i, obj := range objects {
+		if obj.VMagnitude == nil || *obj.VMagnitude != want[i] {
+			t.Errorf("object %d V = %v, want %v in magnitude order", i, obj.VMagnitude, want[i])
+		}
+	}
+
+	limit := 1.0
+	objects, err = client.ConeSearch(context.Background(), catalog.ConeQuery{RA: 83.8, Dec: -1, Radius: 12, MaxMagnitude: &limit, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || *objects[0].VMagnitude != 0.13 {
+		t.Errorf("with magnitude and count limits got %d objects, want Rigel", len(objects))
+	}
+
+	// The type filter is part of the query, so it applies before TOP.
+	nebulae := &catalog.TypeFilter{Codes: []string{"hii", "pn"}}
+	objects, err = client.ConeSearch(context.Background(), catalog.ConeQuery{RA: 83.8, Dec: -1, Radius: 12, Types: nebulae, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].ObjectType != "HII" {
+		t.Errorf("nebulae = %d objects, want M 42", len(objects))
+	}
+	nebulae.Exclude = true
+	objects, err = client.ConeSearch(context.Background(), catalog.ConeQuery{RA: 83.8, Dec: -1, Radius: 12, Types: nebulae, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Errorf("excluding nebulae found %d objects, want the two stars", len(objects))
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	srv := simbadtest.NewServer(simbadtest.DefaultObjects()...)
+	defer srv.Close()
+	srv.SetRateLimit(1, time.Hour)
+	client := newClient(srv)
+
+	if _, err := client.QueryObjects(context.Background(), []string{"Vega"}); err != nil {
+		t.Fatalf("first query: %v", err)
+	}
+	_, err := client.QueryObjects(context.Background(), []string{"Sirius"})
+	var statusErr *httputil.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second query: err = %v, want 429", err)
+	}
+	if statusErr.RetryAfter <= 0 {
+		t.Error("Retry-After not reported")
+	}
+	// A Retry-After beyond the client's maximum delay is not waited out.
+	if n := len(srv.Queries()); n != 2 {
+		t.Errorf("sent %d queries, want 2", n)
+	}
+}
@@ -0,0 +1,98 @@
+package simbadtest
+
+// Object is a fixture row. MainID and Aliases are matched exactly, as
+// SIMBAD matches basic.main_id and ident.id. Fluxes are keyed by filter
+// name. Zero numeric fields are reported as null, the way SIMBAD reports
+// values it does not have.
+type Object struct {
+	MainID         string
+	OType          string
+	SpType         string
+	RA             float64
+	Dec            float64
+	Parallax       float64
+	ParallaxError  float64
+	PMRA           float64
+	PMDec          float64
+	RadialVelocity float64
+	Redshift       float64
+	MajorAxis      float64
+	MinorAxis      float64
+	PositionAngle  float64
+	Fluxes         map[string]float64
+	Aliases        []string
+}
+
+func (o Object) matches(identifier string) bool {
+	if o.MainID == identifier {
+		return true
+	}
+	for _, alias := range o.Aliases {
+		if alias == identifier {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultObjects returns a few bright stars and deep-sky objects, with
+// the identifiers Nova reports for them among their aliases.
+func DefaultObjects() []Object {
+	return []Object{
+		{
+			MainID: "* alf Ori", OType: "s*r", SpType: "M1-M2Ia-Iab",
+			RA: 88.79293899, Dec: 7.40706400, Parallax: 6.55, ParallaxError: 0.83,
+			PMRA: 27.54, PMDec: 11.30, RadialVelocity: 21.91,
+			Fluxes:  map[string]float64{"B": 2.27, "V": 0.42, "J": -3.00, "H": -3.73, "K": -4.38},
+			Aliases: []string{"NAME Betelgeuse", "Betelgeuse", "alf Ori", "HD 39801", "HIP 27989", "HR 2061"},
+		},
+		{
+			MainID: "* bet Ori", OType: "s*b", SpType: "B8Ia",
+			RA: 78.63446707, Dec: -8.20163837, Parallax: 3.78, ParallaxError: 0.56,
+			PMRA: 1.31, PMDec: 0.50, RadialVelocity: 17.8,
+			Fluxes:  map[string]float64{"B": 0.10, "V": 0.13, "J": 0.21, "H": 0.17, "K": 0.18},
+			Aliases: []string{"NAME Rigel", "Rigel", "bet Ori", "HD 34085", "HIP 24436", "HR 1713"},
+		},
+		{
+			MainID: "* alf CMa", OType: "SB*", SpType: "A1V",
+			RA: 101.28715533, Dec: -16.71611586, Parallax: 379.21, ParallaxError: 1.58,
+			PMRA: -546.01, PMDec: -1223.07, RadialVelocity: -5.5,
+			Fluxes:  map[string]float64{"U": -1.51, "B": -1.46, "V": -1.46, "J": -1.36, "H": -1.33, "K": -1.35},
+			Aliases: []string{"NAME Sirius", "Sirius", "alf CMa", "HD 48915", "HIP 32349", "HR 2491"},
+		},
+		{
+			MainID: "* alf Lyr", OType: "dS*", SpType: "A0Va",
+			RA: 279.23473479, Dec: 38.78368896, Parallax: 130.23, ParallaxError: 0.36,
+			PMRA: 200.94, PMDec: 286.23, RadialVelocity: -20.6,
+			Fluxes:  map[string]float64{"U": 0.03, "B": 0.03, "V": 0.03, "J": -0.18, "H": -0.03, "K": 0.13},
+			Aliases: []string{"NAME Vega", "Vega", "alf Lyr", "HD 172167", "HIP 91262", "HR 7001"},
+		},
+		{
+			MainID: "* alf UMi", OType: "cC*", SpType: "F7Ib-IIv SB",
+			RA: 37.95456067, Dec: 89.26410897, Parallax: 7.54, ParallaxError: 0.11,
+			PMRA: 44.48, PMDec: -11.85, RadialVelocity: -16.42,
+			Fluxes:  map[string]float64{"B": 2.58, "V": 1.98},
+			Aliases: []string{"NAME Polaris", "Polaris", "alf UMi", "HD 8890", "HIP 11767", "HR 424"},
+		},
+		{
+			MainID: "M  42", OType: "HII",
+			RA: 83.81860, Dec: -5.38968, MajorAxis: 66, MinorAxis: 60,
+			Fluxes:  map[string]float64{"V": 4.0},
+			Aliases: []string{"M 42", "NGC 1976", "NAME Orion Nebula", "Orion Nebula", "The Great Orion Nebula"},
+		},
+		{
+			MainID: "M  31", OType: "G",
+			RA: 10.68470833, Dec: 41.26875, RadialVelocity: -300, Redshift: -0.001,
+			MajorAxis: 199.53, MinorAxis: 70.79, PositionAngle: 35,
+			Fluxes:  map[string]float64{"B": 4.36, "V": 3.44},
+			Aliases: []string{"M 31", "NGC 224", "NAME Andromeda Galaxy", "Andromeda Galaxy"},
+		},
+		{
+			MainID: "Cl Melotte   22", OType: "OpC",
+			RA: 56.75, Dec: 24.1167, Parallax: 7.364, PMRA: 19.997, PMDec: -45.548,
+			RadialVelocity: 5.65, MajorAxis: 110,
+			Fluxes:  map[string]float64{"V": 1.6},
+			Aliases: []string{"M 45", "Melotte 22", "NAME Pleiades", "Pleiades"},
+		},
+	}
+}
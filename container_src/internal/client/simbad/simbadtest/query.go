@@ -0,0 +1,282 @@
+package simbadtest
+
+import (
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"server/internal/wcs"
+)
+
+var (
+	selectPattern     = regexp.MustCompile(`(?is)^\s*SELECT\s+(?:TOP\s+(\d+)\s+)?(.*?)\s+FROM\s`)
+	mainIDPattern     = regexp.MustCompile(`(?i)\bmain_id\s*=\s*'((?:[^']|'')*)'`)
+	identPattern      = regexp.MustCompile(`(?i)\bid\s*=\s*'((?:[^']|'')*)'`)
+	inListPattern     = regexp.MustCompile(`(?i)\b(?:main_)?id\s+IN\s*\(((?:\s*'(?:[^']|'')*'\s*,?)+)\)`)
+	quotedPattern     = regexp.MustCompile(`'((?:[^']|'')*)'`)
+	aliasPattern      = regexp.MustCompile(`(?i)^(.*?)\s+AS\s+"?(\w+)"?$`)
+	fluxColumnPattern = regexp.MustCompile(`(?i)^(\w)mag$`)
+	circlePattern     = regexp.MustCompile(`(?i)CIRCLE\s*\(\s*'ICRS'\s*,\s*([-+\d.eE]+)\s*,\s*([-+\d.eE]+)\s*,\s*([-+\d.eE]+)\s*\)`)
+	fluxJoinPattern   = regexp.MustCompile(`(?i)JOIN\s+flux\s+AS\s+(\w+)\s+ON\s+[^\n]*?\.filter\s*=\s*'(\w+)'`)
+	fluxLimitPattern  = regexp.MustCompile(`(?i)\b(\w+)\.flux\s*<=\s*([-+\d.eE]+)`)
+	orderPattern      = regexp.MustCompile(`(?i)ORDER\s+BY\s+(\w+)\.flux`)
+	otypePattern      = regexp.MustCompile(`(?i)LOWER\(\s*otype_txt\s*\)\s+(NOT\s+)?IN\s*\(((?:\s*'(?:[^']|'')*'\s*,?)+)\)`)
+)
+
+var errUnsupported = errors.New("unsupported query")
+
+// query is the part of an ADQL statement the fake understands: the
+// selected column names, TOP, and either the identifiers looked up by
+// main_id or through ident, with = or IN, or a cone with an optional
+// magnitude limit, object type condition and ordering on one of the flux
+// joins.
+type query struct {
+	columns     []string
+	top         int
+	identifiers []string
+
+	cone       *cone
+	fluxLimit  *fluxLimit
+	otypes     *otypeFilter
+	orderByMag string
+}
+
+// otypeFilter is a LOWER(otype_txt) IN or NOT IN condition.
+type otypeFilter struct {
+	codes   []string
+	exclude bool
+}
+
+type cone struct {
+	ra, dec, radius float64
+}
+
+type fluxLimit struct {
+	filter string
+	max    float64
+}
+
+// match is an object selected by a query, with the identifier that
+// selected it when the query looked objects up by name.
+type match struct {
+	obj *Object
+	id  string
+}
+
+func parseQuery(adql string) (query, error) {
+	m := selectPattern.FindStringSubmatch(adql)
+	if m == nil {
+		return query{}, errUnsupported
+	}
+
+	var q query
+	if m[1] != "" {
+		q.top, _ = strconv.Atoi(m[1])
+	}
+	for _, item := range strings.Split(m[2], ",") {
+		item = strings.TrimSpace(item)
+		name := item
+		if am := aliasPattern.FindStringSubmatch(item); am != nil {
+			name = am[2]
+		} else if i := strings.LastIndexByte(item, '.'); i >= 0 {
+			name = item[i+1:]
+		}
+		q.columns = append(q.columns, strings.ToLower(name))
+	}
+
+	var quoted []string
+	for _, pattern := range []*regexp.Regexp{mainIDPattern, identPattern} {
+		for _, im := range pattern.FindAllStringSubmatch(adql, -1) {
+			quoted = append(quoted, im[1])
+		}
+	}
+	for _, im := range inListPattern.FindAllStringSubmatch(adql, -1) {
+		for _, qm := range quotedPattern.FindAllStringSubmatch(im[1], -1) {
+			quoted = append(quoted, qm[1])
+		}
+	}
+	for _, raw := range quoted {
+		id := strings.ReplaceAll(raw, "''", "'")
+		if !slices.Contains(q.identifiers, id) {
+			q.identifiers = append(q.identifiers, id)
+		}
+	}
+	if err := q.parseCone(adql); err != nil {
+		return query{}, err
+	}
+	if len(q.identifiers) == 0 && q.cone == nil {
+		return query{}, errUnsupported
+	}
+	return q, nil
+}
+
+func (q *query) parseCone(adql string) error {
+	cm := circlePattern.FindStringSubmatch(adql)
+	if cm == nil {
+		return nil
+	}
+	var c cone
+	for i, v := range []*float64{&c.ra, &c.dec, &c.radius} {
+		f, err := strconv.ParseFloat(cm[i+1], 64)
+		if err != nil {
+			return errUnsupported
+		}
+		*v = f
+	}
+	q.cone = &c
+
+	filters := make(map[string]string)
+	for _, jm := range fluxJoinPattern.FindAllStringSubmatch(adql, -1) {
+		filters[strings.ToLower(jm[1])] = jm[2]
+	}
+	if lm := fluxLimitPattern.FindStringSubmatch(adql); lm != nil {
+		limit, err := strconv.ParseFloat(lm[2], 64)
+		if err != nil {
+			return errUnsupported
+		}
+		q.fluxLimit = &fluxLimit{filter: filters[strings.ToLower(lm[1])], max: limit}
+	}
+	if om := orderPattern.FindStringSubmatch(adql); om != nil {
+		q.orderByMag = filters[strings.ToLower(om[1])]
+	}
+	if tm := otypePattern.FindStringSubmatch(adql); tm != nil {
+		q.otypes = &otypeFilter{exclude: tm[1] != ""}
+		for _, qm := range quotedPattern.FindAllStringSubmatch(tm[2], -1) {
+			q.otypes.codes = append(q.otypes.codes, strings.ReplaceAll(qm[1], "''", "'"))
+		}
+	}
+	return nil
+}
+
+// match selects the rows of objects the query returns, in order.
+func (q query) match(objects []Object) []match {
+	var matches []match
+	if q.cone != nil {
+		for i := range objects {
+			obj := &objects[i]
+			if wcs.Separation(q.cone.ra, q.cone.dec, obj.RA, obj.Dec) > q.cone.radius {
+				continue
+			}
+			if q.fluxLimit != nil {
+				if v, ok := obj.Fluxes[q.fluxLimit.filter]; !ok || v > q.fluxLimit.max {
+					continue
+				}
+			}
+			if q.otypes != nil && slices.Contains(q.otypes.codes, strings.ToLower(obj.OType)) == q.otypes.exclude {
+				continue
+			}
+			matches = append(matches, match{obj: obj})
+		}
+	} else {
+		// Like a join on ident, each matching identifier yields its own
+		// row; without the id column duplicates of an object collapse.
+		perIdentifier := slices.Contains(q.columns, "id")
+		var seen []*Object
+		for _, id := range q.identifiers {
+			for i := range objects {
+				obj := &objects[i]
+				if !obj.matches(id) || (!perIdentifier && slices.Contains(seen, obj)) {
+					continue
+				}
+				seen = append(seen, obj)
+				matches = append(matches, match{obj: obj, id: id})
+			}
+		}
+	}
+
+	if q.orderByMag != "" {
+		slices.SortStableFunc(matches, func(a, b match) int {
+			av, aok := a.obj.Fluxes[q.orderByMag]
+			bv, bok := b.obj.Fluxes[q.orderByMag]
+			switch {
+			case aok && bok:
+				return cmp.Compare(av, bv)
+			case aok:
+				return -1
+			case bok:
+				return 1
+			}
+			return 0
+		})
+	}
+	if q.top > 0 && len(matches) > q.top {
+		matches = matches[:q.top]
+	}
+	return matches
+}
+
+// value returns the column of obj, found by the identifier matched, as
+// SIMBAD's JSON output encodes it; unknown values are null.
+func (o Object) value(column, matched string) any {
+	switch column {
+	case "id":
+		return matched
+	case "main_id":
+		return o.MainID
+	case "otype_txt", "otype":
+		return o.OType
+	case "sp_type":
+		return nullString(o.SpType)
+	case "ra":
+		return o.RA
+	case "dec":
+		return o.Dec
+	case "plx_value", "plx":
+		return nullFloat(o.Parallax)
+	case "plx_err":
+		return nullFloat(o.ParallaxError)
+	case "pmra":
+		return nullFloat(o.PMRA)
+	case "pmdec":
+		return nullFloat(o.PMDec)
+	case "rvz_radvel":
+		return nullFloat(o.RadialVelocity)
+	case "rvz_redshift":
+		return nullFloat(o.Redshift)
+	case "galdim_majaxis":
+		return nullFloat(o.MajorAxis)
+	case "galdim_minaxis":
+		return nullFloat(o.MinorAxis)
+	case "galdim_angle":
+		return nullFloat(o.PositionAngle)
+	case "ids":
+		return strings.Join(append([]string{o.MainID}, o.Aliases...), "|")
+	case "flux":
+		return fluxValue(o.Fluxes, "V")
+	}
+	if m := fluxColumnPattern.FindStringSubmatch(column); m != nil {
+		return fluxValue(o.Fluxes, strings.ToUpper(m[1]))
+	}
+	return nil
+}
+
+func fluxValue(fluxes map[string]float64, filter string) any {
+	if v, ok := fluxes[filter]; ok {
+		return v
+	}
+	return nil
+}
+
+func nullFloat(v float64) any {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
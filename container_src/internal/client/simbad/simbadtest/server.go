@@ -0,0 +1,212 @@
+// Package simbadtest provides a fake of SIMBAD's TAP service
+// (sim-tap/sync) backed by a fixture table, for tests and offline
+// development.
+//
+// It answers the ADQL lookups simbad.Client sends, matching identifiers
+// against each object's main_id and aliases, as well as its cone searches,
+// and returns SIMBAD's JSON result shape. Failures, rate limiting and latency can be injected:
+//
+//	srv := simbadtest.NewServer(simbadtest.DefaultObjects()...)
+//	defer srv.Close()
+//	srv.SetLatency(2 * time.Second)
+//	client := simbad.NewClient(config.SimbadConfig{BaseURL: srv.URL, Timeout: time.Second})
+package simbadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Failure replaces one response. With Status set the handler answers with
+// that HTTP status and optional Retry-After; Disconnect drops the
+// connection instead.
+type Failure struct {
+	Status     int
+	RetryAfter string
+	Disconnect bool
+}
+
+// Handler serves the fake TAP endpoint at any path.
+type Handler struct {
+	mu       sync.Mutex
+	objects  []Object
+	failures []Failure
+	latency  time.Duration
+	queries  []string
+
+	rateLimit  int
+	rateWindow time.Duration
+	windowFrom time.Time
+	windowHits int
+}
+
+func NewHandler(objects ...Object) *Handler {
+	return &Handler{objects: objects}
+}
+
+// Server is a Handler running on an httptest.Server.
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewServer starts a fake serving objects. Call Close when done.
+func NewServer(objects ...Object) *Server {
+	h := NewHandler(objects...)
+	return &Server{Server: httptest.NewServer(h), Handler: h}
+}
+
+// Add appends objects to the fixture table.
+func (h *Handler) Add(objects ...Object) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.objects = append(h.objects, objects...)
+}
+
+// FailNext makes the next request fail as described. Failures queue up.
+func (h *Handler) FailNext(f Failure) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures = append(h.failures, f)
+}
+
+// SetLatency delays every reply by d.
+func (h *Handler) SetLatency(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latency = d
+}
+
+// SetRateLimit answers 429 with a Retry-After once more than n requests
+// arrive within window. A zero n removes the limit.
+func (h *Handler) SetRateLimit(n int, window time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rateLimit, h.rateWindow = n, window
+	h.windowFrom, h.windowHits = time.Time{}, 0
+}
+
+// Queries returns the ADQL of every request received so far.
+func (h *Handler) Queries() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.queries...)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tapError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	adql := r.Form.Get("query")
+
+	h.mu.Lock()
+	h.queries = append(h.queries, adql)
+	delay := h.latency
+	var failure *Failure
+	if len(h.failures) > 0 {
+		failure = &h.failures[0]
+		h.failures = h.failures[1:]
+	} else if retryAfter, limited := h.limited(); limited {
+		failure = &Failure{Status: http.StatusTooManyRequests, RetryAfter: retryAfter}
+	}
+	objects := h.objects
+	h.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if failure != nil {
+		fail(w, *failure)
+		return
+	}
+
+	if r.Form.Get("lang") != "adql" || r.Form.Get("format") != "json" {
+		tapError(w, http.StatusBadRequest, "only lang=adql with format=json is supported")
+		return
+	}
+	q, err := parseQuery(adql)
+	if err != nil {
+		tapError(w, http.StatusBadRequest, fmt.Sprintf("%v: %s", err, adql))
+		return
+	}
+	writeResult(w, q, objects)
+}
+
+// limited counts the request against the rate limit, which must be
+// called with h.mu held.
+func (h *Handler) limited() (string, bool) {
+	if h.rateLimit <= 0 {
+		return "", false
+	}
+	now := time.Now()
+	if now.Sub(h.windowFrom) >= h.rateWindow {
+		h.windowFrom, h.windowHits = now, 0
+	}
+	if h.windowHits++; h.windowHits <= h.rateLimit {
+		return "", false
+	}
+	wait := h.windowFrom.Add(h.rateWindow).Sub(now)
+	return strconv.Itoa(int(wait.Seconds() + 1)), true
+}
+
+type column struct {
+	Name string `json:"name"`
+}
+
+type result struct {
+	Metadata []column `json:"metadata"`
+	Data     [][]any  `json:"data"`
+}
+
+func writeResult(w http.ResponseWriter, q query, objects []Object) {
+	res := result{Metadata: make([]column, len(q.columns)), Data: [][]any{}}
+	for i, name := range q.columns {
+		res.Metadata[i] = column{Name: name}
+	}
+	for _, m := range q.match(objects) {
+		row := make([]any, len(q.columns))
+		for j, name := range q.columns {
+			row[j] = m.obj.value(name, m.id)
+		}
+		res.Data = append(res.Data, row)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("simbadtest: write response: %v", err)
+	}
+}
+
+func fail(w http.ResponseWriter, f Failure) {
+	if f.Disconnect {
+		if conn, _, err := http.NewResponseController(w).Hijack(); err == nil {
+			_ = conn.Close()
+		}
+		return
+	}
+	if f.RetryAfter != "" {
+		w.Header().Set("Retry-After", f.RetryAfter)
+	}
+	tapError(w, f.Status, http.StatusText(f.Status))
+}
+
+// tapError answers like SIMBAD does for a failed query: a VOTable with an
+// ERROR info element.
+func tapError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/x-votable+xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<VOTABLE version="1.3"><RESOURCE type="results"><INFO name="QUERY_STATUS" value="ERROR">%s</INFO></RESOURCE></VOTABLE>
+`, xmlEscape(msg))
+}
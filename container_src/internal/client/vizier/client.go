@@ -0,0 +1,412 @@
+// Package vizier resolves catalogue-numbered objects through VizieR's TAP
+// service: Hipparcos (HIP n), Tycho-2 (TYC a-b-c) and the NGC 2000.0
+// catalogue (NGC n, IC n). Other names are not VizieR's to answer and are
+// reported as not found without a query.
+package vizier
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"server/internal/client/catalog"
+	"server/internal/config"
+	"server/internal/util/httputil"
+	"server/internal/util/ratelimit"
+)
+
+const (
+	hipparcosTable = `"I/239/hip_main"`
+	tychoTable     = `"I/259/tyc2"`
+	ngcTable       = `"VII/118/ngc2000"`
+)
+
+var (
+	hipPattern = regexp.MustCompile(`(?i)^HIP\s*(\d+)$`)
+	tycPattern = regexp.MustCompile(`(?i)^TYC\s*(\d+)-(\d+)-(\d+)$`)
+	ngcPattern = regexp.MustCompile(`(?i)^(NGC|IC)\s*(\d+)$`)
+)
+
+type Client struct {
+	http    *httputil.Client
+	limiter *ratelimit.Limiter
+}
+
+func NewClient(cfg config.VizierConfig) *Client {
+	return &Client{
+		http:    httputil.NewClient(cfg.BaseURL, cfg.Timeout, httputil.RetryPolicy(cfg.Retry)),
+		limiter: ratelimit.New(5, 10),
+	}
+}
+
+// QueryObjects resolves the identifiers it recognises with one query per
+// catalogue. The result maps each requested identifier to its object.
+func (c *Client) QueryObjects(ctx context.Context, identifiers []string) (map[string]*catalog.Object, error) {
+	// Requested identifiers by the catalogue key each row is matched on.
+	hip := make(map[string][]string)
+	tyc := make(map[string][]string)
+	ngc := make(map[string][]string)
+	for _, id := range identifiers {
+		name := strings.TrimSpace(id)
+		if m := hipPattern.FindStringSubmatch(name); m != nil {
+			hip[trimZeros(m[1])] = append(hip[trimZeros(m[1])], id)
+		} else if m := tycPattern.FindStringSubmatch(name); m != nil {
+			key := tycKey(m[1], m[2], m[3])
+			tyc[key] = append(tyc[key], id)
+		} else if m := ngcPattern.FindStringSubmatch(name); m != nil {
+			key := strings.ToUpper(m[1]) + " " + trimZeros(m[2])
+			ngc[key] = append(ngc[key], id)
+		}
+	}
+
+	found := make(map[string]*catalog.Object)
+	if len(hip) > 0 {
+		conditions := fmt.Sprintf("HIP IN (%s)", strings.Join(slices.Sorted(mapKeys(hip)), ", "))
+		if err := c.query(ctx, hipparcosQuery(conditions, 0), parseHipparcos, hip, found); err != nil {
+			return nil, err
+		}
+	}
+	if len(tyc) > 0 {
+		var conditions []string
+		for key := range tyc {
+			parts := strings.Split(key, "-")
+			conditions = append(conditions, fmt.Sprintf("(TYC1 = %s AND TYC2 = %s AND TYC3 = %s)", parts[0], parts[1], parts[2]))
+		}
+		slices.Sort(conditions)
+		query := fmt.Sprintf(`SELECT TYC1, TYC2, TYC3, RAmdeg, DEmdeg, pmRA, pmDE, BTmag, VTmag FROM %s WHERE %s`,
+			tychoTable, strings.Join(conditions, " OR "))
+		if err := c.query(ctx, query, parseTycho, tyc, found); err != nil {
+			return nil, err
+		}
+	}
+	if len(ngc) > 0 {
+		var names []string
+		for key := range ngc {
+			names = append(names, ngcNames(key)...)
+		}
+		slices.Sort(names)
+		conditions := fmt.Sprintf("Name IN (%s)", strings.Join(names, ", "))
+		if err := c.query(ctx, ngcQuery(conditions, 0), parseNGC, ngc, found); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// ConeSearch returns the Hipparcos stars and NGC/IC objects in the cone,
+// ordered by V magnitude with objects lacking one last. The type filter is
+// translated to the NGC types that map to its SIMBAD codes, so the limit
+// applies to matching objects only; Hipparcos is skipped when stars are
+// filtered out, and NGC when none of its types pass.
+func (c *Client) ConeSearch(ctx context.Context, q catalog.ConeQuery) ([]*catalog.Object, error) {
+	circle := fmt.Sprintf("CIRCLE('ICRS', %s, %s, %s)", catalog.FormatFloat(q.RA), catalog.FormatFloat(q.Dec), catalog.FormatFloat(q.Radius))
+	hipConditions := fmt.Sprintf("CONTAINS(POINT('ICRS', RAICRS, DEICRS), %s) = 1", circle)
+	ngcConditions := fmt.Sprintf("CONTAINS(POINT('ICRS', RAB2000, DEB2000), %s) = 1", circle)
+	if q.MaxMagnitude != nil {
+		hipConditions += " AND Vmag <= " + catalog.FormatFloat(*q.MaxMagnitude)
+		ngcConditions += " AND mag <= " + catalog.FormatFloat(*q.MaxMagnitude)
+	}
+	typeCondition, anyNGC := ngcTypeCondition(q.Types)
+	ngcConditions += typeCondition
+
+	var objects []*catalog.Object
+	for _, search := range []struct {
+		query string
+		parse func(catalog.TAPColumns, []any) (string, *catalog.Object)
+		skip  bool
+	}{
+		{hipparcosQuery(hipConditions, q.Limit), parseHipparcos, !q.Types.Match("*")},
+		{ngcQuery(ngcConditions, q.Limit), parseNGC, !anyNGC},
+	} {
+		if search.skip {
+			continue
+		}
+		r, err := c.do(ctx, search.query)
+		if err != nil {
+			return nil, err
+		}
+		columns := r.Columns()
+		for _, row := range r.Data {
+			if _, obj := search.parse(columns, row); obj != nil && q.Types.Match(obj.ObjectType) {
+				objects = append(objects, obj)
+			}
+		}
+	}
+
+	slices.SortStableFunc(objects, func(a, b *catalog.Object) int {
+		switch {
+		case a.VMagnitude != nil && b.VMagnitude != nil:
+			return cmp.Compare(*a.VMagnitude, *b.VMagnitude)
+		case a.VMagnitude != nil:
+			return -1
+		case b.VMagnitude != nil:
+			return 1
+		}
+		return 0
+	})
+	if q.Limit > 0 && len(objects) > q.Limit {
+		objects = objects[:q.Limit]
+	}
+	return objects, nil
+}
+
+// query runs an identifier lookup, filing each row's object under the
+// requested identifiers that share its catalogue key.
+func (c *Client) query(ctx context.Context, query string, parse func(catalog.TAPColumns, []any) (string, *catalog.Object), requested map[string][]string, found map[string]*catalog.Object) error {
+	r, err := c.do(ctx, query)
+	if err != nil {
+		return err
+	}
+	columns := r.Columns()
+	for _, row := range r.Data {
+		key, obj := parse(columns, row)
+		if obj == nil {
+			continue
+		}
+		for _, id := range requested[key] {
+			if _, ok := found[id]; !ok {
+				found[id] = obj
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, query string) (*catalog.TAPResponse, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit: %w", err)
+	}
+	var r catalog.TAPResponse
+	if err := c.http.GetWithParams(ctx, "", catalog.TAPParams(query), &r); err != nil {
+		return nil, fmt.Errorf("vizier request: %w", err)
+	}
+	return &r, nil
+}
+
+func hipparcosQuery(conditions string, limit int) string {
+	query := fmt.Sprintf(`SELECT %sHIP, RAICRS, DEICRS, Vmag, "B-V", Plx, e_Plx, pmRA, pmDE, SpType FROM %s WHERE %s`,
+		top(limit), hipparcosTable, conditions)
+	if limit > 0 {
+		query += " ORDER BY Vmag"
+	}
+	return query
+}
+
+func ngcQuery(conditions string, limit int) string {
+	query := fmt.Sprintf(`SELECT %sName, Type, RAB2000, DEB2000, size, mag FROM %s WHERE %s`,
+		top(limit), ngcTable, conditions)
+	if limit > 0 {
+		query += " ORDER BY mag"
+	}
+	return query
+}
+
+func top(limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("TOP %d ", limit)
+}
+
+func parseHipparcos(columns catalog.TAPColumns, row []any) (string, *catalog.Object) {
+	hip := columns.Float(row, "hip")
+	if hip == nil {
+		return "", nil
+	}
+	key := strconv.Itoa(int(*hip))
+	obj := &catalog.Object{
+		Identifier:    "HIP " + key,
+		ObjectType:    "*",
+		SpectralType:  columns.String(row, "sptype"),
+		Parallax:      columns.Float(row, "plx"),
+		ParallaxError: columns.Float(row, "e_plx"),
+		PMRA:          columns.Float(row, "pmra"),
+		PMDec:         columns.Float(row, "pmde"),
+		RA:            columns.Float(row, "raicrs"),
+		Dec:           columns.Float(row, "deicrs"),
+		Identifiers:   []string{"HIP " + key},
+	}
+	if v := columns.Float(row, "vmag"); v != nil {
+		obj.SetMagnitude("V", *v)
+		if bv := columns.Float(row, "b-v"); bv != nil {
+			obj.SetMagnitude("B", *v+*bv)
+		}
+	}
+	return key, obj
+}
+
+// parseTycho converts Tycho's BT/VT photometry to Johnson B and V with the
+// usual linear approximation.
+func parseTycho(columns catalog.TAPColumns, row []any) (string, *catalog.Object) {
+	t1, t2, t3 := columns.Float(row, "tyc1"), columns.Float(row, "tyc2"), columns.Float(row, "tyc3")
+	if t1 == nil || t2 == nil || t3 == nil {
+		return "", nil
+	}
+	key := tycKey(strconv.Itoa(int(*t1)), strconv.Itoa(int(*t2)), strconv.Itoa(int(*t3)))
+	obj := &catalog.Object{
+		Identifier:  "TYC " + key,
+		ObjectType:  "*",
+		PMRA:        columns.Float(row, "pmra"),
+		PMDec:       columns.Float(row, "pmde"),
+		RA:          columns.Float(row, "ramdeg"),
+		Dec:         columns.Float(row, "demdeg"),
+		Identifiers: []string{"TYC " + key},
+	}
+	bt, vt := columns.Float(row, "btmag"), columns.Float(row, "vtmag")
+	if bt != nil && vt != nil {
+		v := *vt - 0.090*(*bt-*vt)
+		obj.SetMagnitude("V", v)
+		obj.SetMagnitude("B", v+0.850*(*bt-*vt))
+	}
+	return key, obj
+}
+
+// ngcTypes maps NGC 2000.0 object types to the SIMBAD codes classification
+// understands. Uncertain ("?") and unlisted types map to nothing, so the
+// object is classified by its name instead.
+var ngcTypes = map[string]string{
+	"Gx":  "G",
+	"OC":  "OpC",
+	"Gb":  "GlC",
+	"Nb":  "GNe",
+	"Pl":  "PN",
+	"C+N": "OpC",
+	"Ast": "As*",
+	"Kt":  "HII",
+	"*":   "*",
+	"D*":  "**",
+	"***": "**",
+	"?":   "",
+}
+
+// ngcTypeCondition returns the WHERE clause restricting NGC 2000.0 rows to
+// the types whose SIMBAD code passes f, and false when none can. Unlisted
+// types have no code, so they are kept only by an exclusion.
+func ngcTypeCondition(f *catalog.TypeFilter) (string, bool) {
+	if f == nil {
+		return "", true
+	}
+	var listed []string
+	for _, typ := range slices.Sorted(maps.Keys(ngcTypes)) {
+		if f.Match(ngcTypes[typ]) != f.Exclude {
+			listed = append(listed, "'"+catalog.Escape(typ)+"'")
+		}
+	}
+	switch {
+	case !f.Exclude && len(listed) == 0:
+		return "", false
+	case !f.Exclude:
+		return fmt.Sprintf(" AND Type IN (%s)", strings.Join(listed, ", ")), true
+	case len(listed) == 0:
+		return "", true
+	}
+	return fmt.Sprintf(" AND (Type IS NULL OR Type NOT IN (%s))", strings.Join(listed, ", ")), true
+}
+
+// ngcMissing are the NGC 2000.0 types of entries with nothing there: objects
+// found not to exist and defects on the discovery plate.
+var ngcMissing = map[string]bool{"-": true, "PD": true}
+
+func parseNGC(columns catalog.TAPColumns, row []any) (string, *catalog.Object) {
+	name := strings.ReplaceAll(columns.String(row, "name"), " ", "")
+	if name == "" {
+		return "", nil
+	}
+	typ := strings.TrimSpace(columns.String(row, "type"))
+	if ngcMissing[typ] {
+		return "", nil
+	}
+	key := "NGC " + trimZeros(name)
+	if rest, ok := strings.CutPrefix(name, "I"); ok {
+		key = "IC " + trimZeros(rest)
+	}
+
+	obj := &catalog.Object{
+		Identifier:  key,
+		ObjectType:  ngcTypes[typ],
+		RA:          angle(columns, row, "rab2000", 15),
+		Dec:         angle(columns, row, "deb2000", 1),
+		MajorAxis:   columns.Float(row, "size"),
+		Identifiers: []string{key},
+	}
+	if m := columns.Float(row, "mag"); m != nil {
+		obj.SetMagnitude("V", *m)
+	}
+	return key, obj
+}
+
+// angle reads a coordinate given either in degrees or, as NGC 2000.0
+// prints it, sexagesimally ("05 35.4", "-05 27"); scale converts the
+// leading unit to degrees.
+func angle(columns catalog.TAPColumns, row []any, name string, scale float64) *float64 {
+	if v := columns.Float(row, name); v != nil {
+		return v
+	}
+	fields := strings.Fields(columns.String(row, name))
+	if len(fields) == 0 {
+		return nil
+	}
+	sign := 1.0
+	if strings.HasPrefix(fields[0], "-") {
+		sign = -1
+	}
+	var deg float64
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimLeft(f, "+-"), 64)
+		if err != nil {
+			return nil
+		}
+		deg += v / pow60(i)
+	}
+	deg *= sign * scale
+	return &deg
+}
+
+func pow60(n int) float64 {
+	p := 1.0
+	for range n {
+		p *= 60
+	}
+	return p
+}
+
+// ngcNames are the spellings of key's Name column in NGC 2000.0, where IC
+// objects carry an "I" prefix and numbers may be right-aligned.
+func ngcNames(key string) []string {
+	prefix, number, _ := strings.Cut(key, " ")
+	n, _ := strconv.Atoi(number)
+	names := []string{fmt.Sprintf("'%d'", n), fmt.Sprintf("'%4d'", n)}
+	if prefix == "IC" {
+		names = []string{fmt.Sprintf("'I%d'", n), fmt.Sprintf("'I%4d'", n)}
+	}
+	if names[0] == names[1] {
+		names = names[:1]
+	}
+	return names
+}
+
+func tycKey(a, b, c string) string {
+	return trimZeros(a) + "-" + trimZeros(b) + "-" + trimZeros(c)
+}
+
+func trimZeros(n string) string {
+	if t := strings.TrimLeft(n, "0"); t != "" {
+		return t
+	}
+	return "0"
+}
+
+func mapKeys(m map[string][]string) func(func(string) bool) {
+	return func(yield func(string) bool) {
+		for k := range m {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package vizier_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"server/internal/client/catalog"
+	"server/internal/client/vizier"
+	"server/internal/config"
+)
+
+// tapServer answers every query with the testdata fixture of the table it
+// reads, whatever its conditions, and records the queries.
+type tapServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	queries []string
+}
+
+func newTAPServer(t *testing.T) *tapServer {
+	t.Helper()
+	fixtures := map[string][]byte{}
+	for table, file := range map[string]string{
+		`"I/239/hip_main"`:  "hip_main.json",
+		`"I/259/tyc2"`:      "tyc2.json",
+		`"VII/118/ngc2000"`: "ngc2000.json",
+	} {
+		data, err := os.ReadFile("testdata/" + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures[table] = data
+	}
+
+	s := &tapServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		s.mu.Lock()
+		s.queries = append(s.queries, query)
+		s.mu.Unlock()
+		for table, data := range fixtures {
+			if strings.Contains(query, "FROM "+table) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write(data)
+				return
+			}
+		}
+		http.Error(w, "unknown table", http.StatusBadRequest)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *tapServer) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func newClient(srv *tapServer) *vizier.Client {
+	return vizier.NewClient(config.VizierConfig{
+		BaseURL: srv.URL,
+		Timeout: 5 * time.Second,
+		Retry:   config.RetryConfig{MaxAttempts: 1},
+	})
+}
+
+func near(got *float64, want float64) bool {
+	return got != nil && math.Abs(*got-want) < 1e-6
+}
+
+func TestQueryObjects(t *testing.T) {
+	srv := newTAPServer(t)
+	ids := []string{"HIP 27989", "hip 024436", "TYC 4770-1130-1", "TYC 0129-01873-1", "NGC 1976", "NGC 1980", "IC 434", "NGC 2070", "NGC 1977", "M 42"}
+	found, err := newClient(srv).QueryObjects(context.Background(), ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := srv.Queries()
+	if len(queries) != 3 {
+		t.Fatalf("%d queries, want one per catalogue: %q", len(queries), queries)
+	}
+	for _, want := range []string{
+		"HIP IN (24436, 27989)",
+		"(TYC1 = 129 AND TYC2 = 1873 AND TYC3 = 1) OR (TYC1 = 4770 AND TYC2 = 1130 AND TYC3 = 1)",
+		"Name IN ('1976', '1977', '1980', '2070', 'I 434', 'I434')",
+	} {
+		if !strings.Contains(strings.Join(queries, "\n"), want) {
+			t.Errorf("no query contains %q: %q", want, queries)
+		}
+	}
+	if _, ok := found["M 42"]; ok {
+		t.Error("M 42 resolved, but it is not a VizieR catalogue number")
+	}
+
+	hip := found["HIP 27989"]
+	if hip == nil || found["hip 024436"] == nil || found["hip 024436"].Identifier != "HIP 24436" {
+		t.Fatalf("HIP objects = %+v, %+v", hip, found["hip 024436"])
+	}
+	if hip.ObjectType != "*" || hip.SpectralType != "M2Ib" || !near(hip.RA, 88.79287161) || !near(hip.Dec, 7.40703634) {
+		t.Errorf("HIP 27989 = %+v", hip)
+	}
+	if !near(hip.VMagnitude, 0.45) || hip.Magnitudes["B"] != 1.95 || !near(hip.Parallax, 7.63) || !near(hip.ParallaxError, 1.64) {
+		t.Errorf("HIP 27989 photometry = %v, parallax %v", hip.Magnitudes, hip.Parallax)
+	}
+
+	// BT and VT are converted to Johnson B and V; without BT neither is.
+	tyc := found["TYC 4770-1130-1"]
+	if tyc == nil || tyc.Identifier != "TYC 4770-1130-1" || !near(tyc.RA, 83.00166667) || !near(tyc.PMDec, -3.4) {
+		t.Fatalf("TYC 4770-1130-1 = %+v", tyc)
+	}
+	if !near(tyc.VMagnitude, 8.68090) || math.Abs(tyc.Magnitudes["B"]-9.09740) > 1e-6 {
+		t.Errorf("TYC 4770-1130-1 magnitudes = %v", tyc.Magnitudes)
+	}
+	if tyc := found["TYC 0129-01873-1"]; tyc == nil || tyc.Identifier != "TYC 129-1873-1" || tyc.VMagnitude != nil {
+		t.Errorf("TYC 129-1873-1 = %+v", tyc)
+	}
+
+	tests := []struct {
+		id, otype string
+		ra, dec   float64
+		size      float64
+	}{
+		{"NGC 1976", "GNe", 83.825, -5.4, 66},
+		{"NGC 1980", "OpC", 83.8, -5.916667, 14},
+		{"IC 434", "GNe", 85.25, -2.4, 60},
+		// An uncertain type is left for classification by name.
+		{"NGC 2070", "", 84.675, -69.1, 40},
+	}
+	for _, tt := range tests {
+		obj := found[tt.id]
+		if obj == nil {
+			t.Errorf("%s not found", tt.id)
+			continue
+		}
+		if obj.Identifier != tt.id || obj.ObjectType != tt.otype || !near(obj.MajorAxis, tt.size) {
+			t.Errorf("%s = %q type %q size %v", tt.id, obj.Identifier, obj.ObjectType, obj.MajorAxis)
+		}
+		if !near(obj.RA, tt.ra) || !near(obj.Dec, tt.dec) {
+			t.Errorf("%s position = %v, %v, want %v, %v", tt.id, *obj.RA, *obj.Dec, tt.ra, tt.dec)
+		}
+	}
+	// The fixture's NGC 1977 row is marked as not existing ("-").
+	if _, ok := found["NGC 1977"]; ok {
+		t.Error("NGC 1977 resolved from a missing entry")
+	}
+}
+
+func TestConeSearchTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		types *catalog.TypeFilter
+		// where is the NGC query's type condition, or "" for no NGC query.
+		where     string
+		hipparcos bool
+		want      []string
+	}{
+		{
+			name:      "any",
+			where:     "CONTAINS(POINT('ICRS', RAB2000, DEB2000), CIRCLE('ICRS', 83.8, -5.4, 1)) = 1 ORDER BY mag",
+			hipparcos: true,
+			want:      []string{"HIP 24436", "HIP 27989", "NGC 1980", "NGC 1976", "NGC 2070", "IC 434"},
+		},
+		{
+			name:  "nebulae",
+			types: &catalog.TypeFilter{Codes: []string{"GNe", "HII"}},
+			where: "AND Type IN ('Kt', 'Nb') ORDER BY mag",
+			want:  []string{"NGC 1976", "IC 434"},
+		},
+		{
+			name:  "not stars",
+			types: &catalog.TypeFilter{Codes: []string{"*", "**"}, Exclude: true},
+			where: "AND (Type IS NULL OR Type NOT IN ('*', '***', 'D*')) ORDER BY mag",
+			want:  []string{"NGC 1980", "NGC 1976", "NGC 2070", "IC 434"},
+		},
+		{
+			name:  "quasars",
+			types: &catalog.TypeFilter{Codes: []string{"QSO"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTAPServer(t)
+			objects, err := newClient(srv).ConeSearch(context.Background(), catalog.ConeQuery{RA: 83.8, Dec: -5.4, Radius: 1, Types: tt.types, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var hipparcos bool
+			var ngc string
+			for _, q := range srv.Queries() {
+				switch {
+				case strings.Contains(q, "hip_main"):
+					hipparcos = true
+				case strings.Contains(q, "ngc2000"):
+					ngc = q
+				}
+			}
+			if hipparcos != tt.hipparcos {
+				t.Errorf("Hipparcos queried = %v, want %v", hipparcos, tt.hipparcos)
+			}
+			if (ngc == "") != (tt.where == "") || !strings.HasSuffix(ngc, tt.where) {
+				t.Errorf("NGC query = %q, want it to end in %q", ngc, tt.where)
+			}
+			if ngc != "" && !strings.HasPrefix(ngc, "SELECT TOP 10 ") {
+				t.Errorf("NGC query = %q, want the limit applied by VizieR", ngc)
+			}
+
+			var got []string
+			for _, obj := range objects {
+				got = append(got, obj.Identifier)
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("objects = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,20 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server ServerConfig
-	Nova   NovaConfig
-	Simbad SimbadConfig
-	KV     KVConfig
+	Server  ServerConfig
+	Nova    NovaConfig
+	Simbad  SimbadConfig
+	Vizier  VizierConfig
+	Catalog CatalogConfig
+	KV      KVConfig
+	Solve   SolveConfig
+	Webhook WebhookConfig
 }
 
 type ServerConfig struct {
@@ -19,14 +25,71 @@ type ServerConfig struct {
 }
 
 type NovaConfig struct {
+	BaseURL    string
+	APIKey     string
+	Timeout    time.Duration
+	SessionTTL time.Duration
+	Retry      RetryConfig
+	Breaker    BreakerConfig
+}
+
+type SimbadConfig struct {
 	BaseURL string
-	APIKey  string
 	Timeout time.Duration
+	Retry   RetryConfig
+	Breaker BreakerConfig
 }
 
-type SimbadConfig struct {
+type VizierConfig struct {
 	BaseURL string
 	Timeout time.Duration
+	Retry   RetryConfig
+	Breaker BreakerConfig
+}
+
+// CatalogConfig orders the catalogs objects are resolved through, by name
+// ("simbad", "vizier", "local"). LocalFile is the JSON object list the
+// local catalog serves.
+type CatalogConfig struct {
+	Resolvers []string
+	LocalFile string
+}
+
+// RetryConfig is the retry policy for an upstream API. Timeout on the
+// owning config applies to each attempt.
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+type SolveConfig struct {
+	PollMinInterval  time.Duration
+	PollMaxInterval  time.Duration
+	WatchMaxDuration time.Duration
+	BatchConcurrency int
+	BatchMaxImages   int
+	MaxImageEdge     int
+	MaxImagePixels   int
+	MaxUploadBytes   int64
+	MaxBatchBytes    int64
+}
+
+type WebhookConfig struct {
+	Secret      string
+	Enabled     bool
+	Timeout     time.Duration
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// BreakerConfig sets when an upstream's circuit breaker opens and how long
+// it stays open before probing.
+type BreakerConfig struct {
+	FailureThreshold int
+	SuccessThreshold int
+	OpenTimeout      time.Duration
 }
 
 type KVConfig struct {
@@ -36,6 +99,7 @@ type KVConfig struct {
 	APIToken    string
 	Enabled     bool
 	Timeout     time.Duration
+	Breaker     BreakerConfig
 }
 
 func Load() *Config {
@@ -46,15 +110,58 @@ func Load() *Config {
 			WriteTimeout: getDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
 		},
 		Nova: NovaConfig{
-			BaseURL: getEnv("NOVA_BASE_URL", "https://nova.astrometry.net"),
-			APIKey:  os.Getenv("NOVA_API_KEY"),
-			Timeout: getDuration("NOVA_TIMEOUT", 30*time.Second),
+			BaseURL:    getEnv("NOVA_BASE_URL", "https://nova.astrometry.net"),
+			APIKey:     os.Getenv("NOVA_API_KEY"),
+			Timeout:    getDuration("NOVA_TIMEOUT", 30*time.Second),
+			SessionTTL: getDuration("NOVA_SESSION_TTL", time.Hour),
+			Retry:      loadRetryConfig("NOVA"),
+			Breaker:    loadBreakerConfig("NOVA"),
 		},
 		Simbad: SimbadConfig{
 			BaseURL: getEnv("SIMBAD_BASE_URL", "https://simbad.u-strasbg.fr/simbad/sim-tap/sync"),
 			Timeout: getDuration("SIMBAD_TIMEOUT", 10*time.Second),
+			Retry:   loadRetryConfig("SIMBAD"),
+			Breaker: loadBreakerConfig("SIMBAD"),
+		},
+		Vizier: VizierConfig{
+			BaseURL: getEnv("VIZIER_BASE_URL", "https://tapvizier.cds.unistra.fr/TAPVizieR/tap/sync"),
+			Timeout: getDuration("VIZIER_TIMEOUT", 10*time.Second),
+			Retry:   loadRetryConfig("VIZIER"),
+			Breaker: loadBreakerConfig("VIZIER"),
+		},
+		Catalog: CatalogConfig{
+			Resolvers: getList("CATALOG_RESOLVERS", []string{"simbad", "vizier"}),
+			LocalFile: os.Getenv("CATALOG_LOCAL_FILE"),
 		},
 		KV: loadKVConfig(),
+		Solve: SolveConfig{
+			PollMinInterval:  getDuration("SOLVE_POLL_MIN_INTERVAL", 2*time.Second),
+			PollMaxInterval:  getDuration("SOLVE_POLL_MAX_INTERVAL", 30*time.Second),
+			WatchMaxDuration: getDuration("SOLVE_WATCH_MAX_DURATION", time.Hour),
+			BatchConcurrency: getInt("SOLVE_BATCH_CONCURRENCY", 4),
+			BatchMaxImages:   getInt("SOLVE_BATCH_MAX_IMAGES", 50),
+			MaxImageEdge:     getInt("SOLVE_MAX_IMAGE_EDGE", 4096),
+			MaxImagePixels:   getInt("SOLVE_MAX_IMAGE_PIXELS", 100_000_000),
+			MaxUploadBytes:   int64(getInt("SOLVE_MAX_UPLOAD_BYTES", 64<<20)),
+			MaxBatchBytes:    int64(getInt("SOLVE_MAX_BATCH_BYTES", 512<<20)),
+		},
+		Webhook: loadWebhookConfig(),
+	}
+}
+
+func loadRetryConfig(prefix string) RetryConfig {
+	return RetryConfig{
+		MaxAttempts: getInt(prefix+"_RETRY_MAX_ATTEMPTS", 3),
+		BaseDelay:   getDuration(prefix+"_RETRY_BASE_DELAY", 500*time.Millisecond),
+		MaxDelay:    getDuration(prefix+"_RETRY_MAX_DELAY", 10*time.Second),
+	}
+}
+
+func loadBreakerConfig(prefix string) BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: getInt(prefix+"_BREAKER_FAILURES", 5),
+		SuccessThreshold: getInt(prefix+"_BREAKER_SUCCESSES", 1),
+		OpenTimeout:      getDuration(prefix+"_BREAKER_OPEN_TIMEOUT", 30*time.Second),
 	}
 }
 
@@ -70,6 +177,20 @@ func loadKVConfig() KVConfig {
 		APIToken:    apiToken,
 		Enabled:     accountID != "" && namespaceID != "" && apiToken != "",
 		Timeout:     getDuration("KV_TIMEOUT", 5*time.Second),
+		Breaker:     loadBreakerConfig("KV"),
+	}
+}
+
+func loadWebhookConfig() WebhookConfig {
+	secret := os.Getenv("WEBHOOK_SECRET")
+
+	return WebhookConfig{
+		Secret:      secret,
+		Enabled:     secret != "",
+		Timeout:     getDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		MaxAttempts: getInt("WEBHOOK_MAX_ATTEMPTS", 6),
+		BaseDelay:   getDuration("WEBHOOK_BASE_DELAY", 2*time.Second),
+		MaxDelay:    getDuration("WEBHOOK_MAX_DELAY", 5*time.Minute),
 	}
 }
 
@@ -88,3 +209,25 @@ func getDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getInt(key string, defaultValue int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getList(key string, defaultValue []string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, strings.ToLower(v))
+		}
+	}
+	if len(list) == 0 {
+		return defaultValue
+	}
+	return list
+}
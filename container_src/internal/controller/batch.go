@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5"
+
+	apperrors "server/internal/errors"
+	"server/internal/service"
+	"server/internal/util/httputil"
+	"server/internal/view"
+)
+
+// SubmitBatch reads the multipart body as a stream, like SubmitImage.
+// Fields must come before the first image. Each image is copied to a
+// temporary file as it arrives, since the body has to be read to the end
+// before the images can be uploaded concurrently.
+func (c *SolveController) SubmitBatch(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, c.maxBatchBytes)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return apperrors.NewValidationError("invalid form")
+	}
+
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			if err := removeFile(f); err != nil {
+				log.Printf("failed to remove batch file: %v", err)
+			}
+		}
+	}()
+
+	fields := make(map[string]string)
+	var uploads []service.Upload
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return c.bodyError(err, "invalid form")
+		}
+		if part.FormName() == "image" {
+			if len(uploads) == c.batchMaxImages {
+				return apperrors.NewValidationError(fmt.Sprintf("at most %d images per batch", c.batchMaxImages))
+			}
+			file, err := spoolPart(part, c.maxUploadBytes)
+			if err != nil {
+				return c.bodyError(err, "")
+			}
+			files = append(files, file)
+			uploads = append(uploads, service.Upload{File: file, Filename: part.FileName()})
+			continue
+		}
+		if part.FileName() != "" {
+			continue
+		}
+		if len(uploads) > 0 {
+			return apperrors.NewValidationError(fmt.Sprintf("field %q must come before the images", part.FormName()))
+		}
+		value, err := readField(part)
+		if err != nil {
+			return c.bodyError(err, "invalid form")
+		}
+		fields[part.FormName()] = value
+	}
+	if len(uploads) == 0 {
+		return apperrors.NewValidationError("missing image")
+	}
+
+	opts, err := parseSubmitOptions(r.Context(), formGetter(r, fields))
+	if err != nil {
+		return err
+	}
+
+	group, err := c.service.SubmitBatch(r.Context(), uploads, opts)
+	if err != nil {
+		return fmt.Errorf("submit batch: %w", err)
+	}
+	httputil.WriteJSON(w, http.StatusAccepted, view.NewBatchSubmitResponse(group))
+	return nil
+}
+
+// spoolPart copies an image part of at most limit bytes to a temporary
+// file positioned at its start.
+func spoolPart(part *multipart.Part, limit int64) (*os.File, error) {
+	f, err := os.CreateTemp("", "batch-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	n, err := io.Copy(f, io.LimitReader(part, limit+1))
+	if err != nil {
+		_ = removeFile(f)
+		return nil, fmt.Errorf("read image %q: %w", part.FileName(), err)
+	}
+	if n > limit {
+		_ = removeFile(f)
+		return nil, apperrors.NewTooLargeError(fmt.Sprintf("image %q exceeds %d bytes", part.FileName(), limit))
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		_ = removeFile(f)
+		return nil, fmt.Errorf("rewind image %q: %w", part.FileName(), err)
+	}
+	return f, nil
+}
+
+func removeFile(f *os.File) error {
+	return errors.Join(f.Close(), os.Remove(f.Name()))
+}
+
+func (c *SolveController) GetBatchStatus(w http.ResponseWriter, r *http.Request) error {
+	groupID := chi.URLParam(r, "groupId")
+	if groupID == "" {
+		return apperrors.NewValidationError("invalid groupId")
+	}
+	result, err := c.service.GetBatch(r.Context(), groupID, r.URL.Query().Get("fetch") == "true")
+	if err != nil {
+		return err
+	}
+	httputil.WriteJSON(w, http.StatusOK, view.NewBatchStatusResponse(result))
+	return nil
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"net/http"
+
+	"server/internal/util/breaker"
+	"server/internal/util/httputil"
+	"server/internal/view"
+)
+
+type HealthController struct {
+	breakers []*breaker.Breaker
+}
+
+func NewHealthController(breakers ...*breaker.Breaker) *HealthController {
+	return &HealthController{breakers: breakers}
+}
+
+// Health handles GET /api/health
+func (c *HealthController) Health(w http.ResponseWriter, r *http.Request) error {
+	statuses := make([]breaker.Status, 0, len(c.breakers))
+	for _, b := range c.breakers {
+		statuses = append(statuses, b.Status())
+	}
+	httputil.WriteJSON(w, http.StatusOK, view.NewHealthResponse(statuses))
+	return nil
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	apperrors "server/internal/errors"
+	"server/internal/model"
+	"server/internal/util/httputil"
+)
+
+// parseSubmitOptions reads the hints and callback URL shared by every
+// submission endpoint.
+func parseSubmitOptions(ctx context.Context, get func(key string) string) (model.SubmitOptions, error) {
+	hints, err := parseHints(get)
+	if err != nil {
+		return model.SubmitOptions{}, err
+	}
+	opts := model.SubmitOptions{Hints: hints}
+
+	if raw := strings.TrimSpace(get("callbackUrl")); raw != "" {
+		callbackURL, err := httputil.ValidatePublicURL(ctx, raw)
+		if err != nil {
+			return opts, apperrors.NewValidationError(fmt.Sprintf("invalid callbackUrl: %v", err))
+		}
+		opts.CallbackURL = callbackURL.String()
+	}
+	return opts, nil
+}
+
+// parseHints reads the optional Nova solver fields from a form lookup
+// and validates them.
+func parseHints(get func(key string) string) (model.SolveHints, error) {
+	var h model.SolveHints
+	var err error
+
+	h.ScaleUnits = model.ScaleUnits(strings.ToLower(strings.TrimSpace(get("scale_units"))))
+
+	floats := []struct {
+		key string
+		dst **float64
+	}{
+		{"scale_lower", &h.ScaleLower},
+		{"scale_upper", &h.ScaleUpper},
+		{"scale_est", &h.ScaleEst},
+		{"scale_err", &h.ScaleErr},
+		{"center_ra", &h.CenterRA},
+		{"center_dec", &h.CenterDec},
+		{"radius", &h.Radius},
+		{"downsample_factor", &h.DownsampleFactor},
+	}
+	for _, f := range floats {
+		if *f.dst, err = parseOptionalFloat(get, f.key); err != nil {
+			return h, err
+		}
+	}
+
+	if h.TweakOrder, err = parseOptionalInt(get, "tweak_order"); err != nil {
+		return h, err
+	}
+	if h.Parity, err = parseOptionalInt(get, "parity"); err != nil {
+		return h, err
+	}
+	if h.UseSextractor, err = parseOptionalBool(get, "use_sextractor"); err != nil {
+		return h, err
+	}
+	if h.CrpixCenter, err = parseOptionalBool(get, "crpix_center"); err != nil {
+		return h, err
+	}
+
+	if err := h.Validate(); err != nil {
+		return h, apperrors.NewValidationError(err.Error())
+	}
+	return h, nil
+}
+
+// jsonFieldGetter adapts a decoded JSON object to the lookup used by
+// parseHints. Strings are unquoted; numbers and booleans keep their literal text.
+func jsonFieldGetter(body map[string]json.RawMessage) func(string) string {
+	return func(key string) string {
+		raw, ok := body[key]
+		if !ok || string(raw) == "null" {
+			return ""
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+		return string(raw)
+	}
+}
+
+func parseOptionalFloat(get func(string) string, key string) (*float64, error) {
+	raw := strings.TrimSpace(get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("invalid %s", key))
+	}
+	return &v, nil
+}
+
+func parseOptionalInt(get func(string) string, key string) (*int, error) {
+	raw := strings.TrimSpace(get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("invalid %s", key))
+	}
+	return &v, nil
+}
+
+func parseOptionalBool(get func(string) string, key string) (*bool, error) {
+	raw := strings.TrimSpace(get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("invalid %s", key))
+	}
+	return &v, nil
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	apperrors "server/internal/errors"
+	"server/internal/service"
+	"server/internal/util/httputil"
+	"server/internal/view"
+)
+
+type ObjectController struct {
+	service service.ObjectService
+}
+
+func NewObjectController(svc service.ObjectService) *ObjectController {
+	return &ObjectController{service: svc}
+}
+
+// GetObject handles GET /api/objects/{identifier}
+// The identifier is any name SIMBAD or Nova uses, such as "M 42",
+// "Betelgeuse" or "α Ori".
+func (c *ObjectController) GetObject(w http.ResponseWriter, r *http.Request) error {
+	identifier := chi.URLParam(r, "identifier")
+	// chi matches against the raw path when it has escapes of its own, such
+	// as %2F, leaving the parameter escaped.
+	if r.URL.RawPath != "" {
+		unescaped, err := url.PathUnescape(identifier)
+		if err != nil {
+			return apperrors.NewValidationError("invalid identifier")
+		}
+		identifier = unescaped
+	}
+
+	obj, err := c.service.Lookup(r.Context(), identifier)
+	if err != nil {
+		return err
+	}
+	httputil.WriteJSON(w, http.StatusOK, view.NewObjectResponse(obj))
+	return nil
+}
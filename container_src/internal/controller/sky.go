@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	apperrors "server/internal/errors"
+	"server/internal/model"
+	"server/internal/util/httputil"
+	"server/internal/view"
+)
+
+const (
+	maxConeRadius    = 10.0
+	defaultConeLimit = 50
+	maxConeLimit     = 200
+)
+
+// coneTypes are the values accepted in the types filter.
+var coneTypes = map[string]bool{
+	string(model.ObjectTypeStar):     true,
+	string(model.ObjectTypeDSO):      true,
+	string(model.DSOOpenCluster):     true,
+	string(model.DSOGlobularCluster): true,
+	string(model.DSOGalaxy):          true,
+	string(model.DSONebula):          true,
+	string(model.DSOSupernova):       true,
+}
+
+// ConeSearch handles GET /api/sky/cone?ra=&dec=&radius=&maxMag=&types=&limit=&offset=
+// ra, dec and radius are J2000 degrees; types is a comma-separated list of
+// object types such as STAR, DEEP_SKY_OBJECT or GALAXY.
+func (c *ObjectController) ConeSearch(w http.ResponseWriter, r *http.Request) error {
+	var q model.ConeQuery
+	var err error
+	if q.RA, err = requiredFloat(r, "ra"); err != nil {
+		return err
+	}
+	if q.Dec, err = requiredFloat(r, "dec"); err != nil {
+		return err
+	}
+	if q.Radius, err = requiredFloat(r, "radius"); err != nil {
+		return err
+	}
+	if q.RA < 0 || q.RA >= 360 {
+		return apperrors.NewValidationError("ra must be in [0, 360)")
+	}
+	if q.Dec < -90 || q.Dec > 90 {
+		return apperrors.NewValidationError("dec must be in [-90, 90]")
+	}
+	if q.Radius <= 0 || q.Radius > maxConeRadius {
+		return apperrors.NewValidationError("radius must be in (0, 10]")
+	}
+
+	if r.URL.Query().Get("maxMag") != "" {
+		maxMag, err := requiredFloat(r, "maxMag")
+		if err != nil {
+			return err
+		}
+		q.MaxMagnitude = &maxMag
+	}
+
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if !coneTypes[t] {
+			return apperrors.NewValidationError("unknown type " + t)
+		}
+		q.Types = append(q.Types, t)
+	}
+
+	if q.Limit, err = optionalInt(r, "limit", defaultConeLimit); err != nil {
+		return err
+	}
+	if q.Limit < 1 || q.Limit > maxConeLimit {
+		return apperrors.NewValidationError("limit must be between 1 and 200")
+	}
+	if q.Offset, err = optionalInt(r, "offset", 0); err != nil {
+		return err
+	}
+	if q.Offset < 0 {
+		return apperrors.NewValidationError("offset must not be negative")
+	}
+
+	result, err := c.service.ConeSearch(r.Context(), q)
+	if err != nil {
+		return err
+	}
+	httputil.WriteJSON(w, http.StatusOK, view.NewConeSearchResponse(result, q.Offset, q.Limit))
+	return nil
+}
+
+func optionalInt(r *http.Request, key string, def int) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, apperrors.NewValidationError("invalid " + key)
+	}
+	return v, nil
+}
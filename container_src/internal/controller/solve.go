@@ -1,10 +1,15 @@
 package controller
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
@@ -15,33 +20,173 @@ import (
 	"server/internal/view"
 )
 
+const (
+	sseKeepAlive  = 15 * time.Second
+	maxFieldBytes = 64 << 10
+)
+
 type SolveController struct {
-	service service.SolveService
+	service        service.SolveService
+	batchMaxImages int
+	maxUploadBytes int64
+	maxBatchBytes  int64
 }
 
-func NewSolveController(svc service.SolveService) *SolveController {
-	return &SolveController{service: svc}
+// NewSolveController returns a controller that limits a single upload,
+// and each image of a batch, to maxUploadBytes and a whole batch request
+// to maxBatchBytes.
+func NewSolveController(svc service.SolveService, batchMaxImages int, maxUploadBytes, maxBatchBytes int64) *SolveController {
+	return &SolveController{
+		service:        svc,
+		batchMaxImages: batchMaxImages,
+		maxUploadBytes: maxUploadBytes,
+		maxBatchBytes:  maxBatchBytes,
+	}
 }
 
+// SubmitImage reads the multipart body as a stream. Form fields are
+// collected until the image part, which is passed on without being
+// buffered here, so hint fields must come before the image. A request
+// with any part after the image is rejected rather than having those
+// fields silently ignored.
 func (c *SolveController) SubmitImage(w http.ResponseWriter, r *http.Request) error {
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
+	r.Body = http.MaxBytesReader(w, r.Body, c.maxUploadBytes)
+	reader, err := r.MultipartReader()
+	if err != nil {
 		return apperrors.NewValidationError("invalid form")
 	}
-	file, header, err := r.FormFile("image")
-	if err != nil {
-		return apperrors.NewValidationError("missing image")
+
+	fields := make(map[string]string)
+	get := formGetter(r, fields)
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return apperrors.NewValidationError("missing image")
+		}
+		if err != nil {
+			return c.bodyError(err, "invalid form")
+		}
+		if part.FormName() == "image" {
+			return c.submitPart(w, r, &lastPart{Part: part, reader: reader}, get)
+		}
+		if part.FileName() != "" {
+			continue
+		}
+		value, err := readField(part)
+		if err != nil {
+			return c.bodyError(err, "invalid form")
+		}
+		fields[part.FormName()] = value
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			log.Printf("failed to close file: %v", err)
+}
+
+// formGetter looks a key up in the form fields read so far, falling back
+// to the query string.
+func formGetter(r *http.Request, fields map[string]string) func(string) string {
+	return func(key string) string {
+		if v, ok := fields[key]; ok {
+			return v
 		}
-	}()
+		return r.URL.Query().Get(key)
+	}
+}
+
+func readField(part *multipart.Part) (string, error) {
+	value, err := io.ReadAll(io.LimitReader(part, maxFieldBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(value) > maxFieldBytes {
+		return "", apperrors.NewValidationError(fmt.Sprintf("field %q is too long", part.FormName()))
+	}
+	return string(value), nil
+}
+
+// lastPart is an image part that must end the form. Once it has been
+// read to the end, a following part turns the EOF into a validation
+// error, which fails the submission before anything is uploaded.
+type lastPart struct {
+	*multipart.Part
+	reader *multipart.Reader
+	err    error
+}
+
+func (p *lastPart) Read(b []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	n, err := p.Part.Read(b)
+	if !errors.Is(err, io.EOF) {
+		return n, err
+	}
+	p.err = io.EOF
+	next, nextErr := p.reader.NextPart()
+	switch {
+	case errors.Is(nextErr, io.EOF):
+	case nextErr != nil:
+		p.err = nextErr
+	default:
+		p.err = apperrors.NewValidationError(fmt.Sprintf("field %q must come before the image", next.FormName()))
+	}
+	return n, p.err
+}
+
+func (c *SolveController) submitPart(w http.ResponseWriter, r *http.Request, part *lastPart, get func(string) string) error {
+	opts, err := parseSubmitOptions(r.Context(), get)
+	if err != nil {
+		return err
+	}
+	sub, err := c.service.Submit(r.Context(), part, part.FileName(), opts)
+	if err != nil {
+		return c.bodyError(err, "")
+	}
+	httputil.WriteJSON(w, http.StatusAccepted, view.NewSubmitResponse(sub))
+	return nil
+}
+
+// bodyError reports a request body over its size limit as 413. API
+// errors are kept; other errors become a validation error with msg, or
+// are passed through when msg is empty.
+func (c *SolveController) bodyError(err error, msg string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apperrors.NewTooLargeError(fmt.Sprintf("upload exceeds %d bytes", tooLarge.Limit))
+	}
+	var apiErr *apperrors.APIError
+	if errors.As(err, &apiErr) {
+		return err
+	}
+	if msg != "" {
+		return apperrors.NewValidationError(msg)
+	}
+	return fmt.Errorf("submit: %w", err)
+}
+
+func (c *SolveController) SubmitURL(w http.ResponseWriter, r *http.Request) error {
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&body); err != nil {
+		return apperrors.NewValidationError("invalid JSON body")
+	}
+	get := jsonFieldGetter(body)
 
-	subID, err := c.service.Submit(r.Context(), file, header.Filename)
+	opts, err := parseSubmitOptions(r.Context(), get)
 	if err != nil {
-		return fmt.Errorf("submit: %w", err)
+		return err
+	}
+	rawURL := get("url")
+	if rawURL == "" {
+		return apperrors.NewValidationError("missing url")
+	}
+	imageURL, err := httputil.ValidatePublicURL(r.Context(), rawURL)
+	if err != nil {
+		return apperrors.NewValidationError(fmt.Sprintf("invalid url: %v", err))
 	}
-	httputil.WriteJSON(w, http.StatusAccepted, view.SubmitResponse{JobID: fmt.Sprintf("%d", subID)})
+
+	sub, err := c.service.SubmitURL(r.Context(), imageURL.String(), opts)
+	if err != nil {
+		return fmt.Errorf("submit url: %w", err)
+	}
+	httputil.WriteJSON(w, http.StatusAccepted, view.NewSubmitResponse(sub))
 	return nil
 }
 
@@ -58,11 +203,56 @@ func (c *SolveController) GetSolveStatus(w http.ResponseWriter, r *http.Request)
 	return nil
 }
 
+func (c *SolveController) StreamSolveEvents(w http.ResponseWriter, r *http.Request) error {
+	jobID, err := strconv.Atoi(chi.URLParam(r, "jobId"))
+	if err != nil {
+		return apperrors.NewValidationError("invalid jobId")
+	}
+	updates, err := c.service.Subscribe(r.Context(), jobID)
+	if err != nil {
+		return err
+	}
+	stream, err := httputil.NewEventStream(w)
+	if err != nil {
+		return fmt.Errorf("open event stream: %w", err)
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-keepAlive.C:
+			if err := stream.Comment("keep-alive"); err != nil {
+				return nil
+			}
+		case result, ok := <-updates:
+			if !ok {
+				if err := stream.Send("error", map[string]string{"error": "stream closed"}); err != nil {
+					log.Printf("write event: %v", err)
+				}
+				return nil
+			}
+			if err := stream.Send("status", view.NewSolveStatusResponse(result)); err != nil {
+				return nil
+			}
+			if result.IsFinal() {
+				return nil
+			}
+		}
+	}
+}
+
 func (c *SolveController) CancelSolve(w http.ResponseWriter, r *http.Request) error {
-	jobID := chi.URLParam(r, "jobId")
-	if _, err := strconv.Atoi(jobID); err != nil {
+	jobID, err := strconv.Atoi(chi.URLParam(r, "jobId"))
+	if err != nil {
 		return apperrors.NewValidationError("invalid jobId")
 	}
-	httputil.WriteJSON(w, http.StatusOK, view.CancelResponse{JobID: jobID, Status: string(model.StatusCancelled)})
+	if err := c.service.Cancel(r.Context(), jobID); err != nil {
+		return fmt.Errorf("cancel: %w", err)
+	}
+	httputil.WriteJSON(w, http.StatusOK, view.CancelResponse{JobID: fmt.Sprintf("%d", jobID), Status: string(model.StatusCancelled)})
 	return nil
 }
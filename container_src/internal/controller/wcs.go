@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	apperrors "server/internal/errors"
+	"server/internal/util/httputil"
+	"server/internal/view"
+)
+
+// PixelToSky handles GET /api/solve/{jobId}/pixel-to-sky?x=&y=
+// Pixel coordinates are zero-based from the top-left of the uploaded image
+// as displayed, whatever size it was solved at.
+func (c *SolveController) PixelToSky(w http.ResponseWriter, r *http.Request) error {
+	jobID, err := strconv.Atoi(chi.URLParam(r, "jobId"))
+	if err != nil {
+		return apperrors.NewValidationError("invalid jobId")
+	}
+	x, err := requiredFloat(r, "x")
+	if err != nil {
+		return err
+	}
+	y, err := requiredFloat(r, "y")
+	if err != nil {
+		return err
+	}
+
+	solution, err := c.service.Solution(r.Context(), jobID)
+	if err != nil {
+		return err
+	}
+	ra, dec := solution.ImageToSky(x, y)
+	httputil.WriteJSON(w, http.StatusOK, view.NewSkyPosition(x, y, ra, dec, solution.Contains(x, y)))
+	return nil
+}
+
+// SkyToPixel handles GET /api/solve/{jobId}/sky-to-pixel?ra=&dec=
+func (c *SolveController) SkyToPixel(w http.ResponseWriter, r *http.Request) error {
+	jobID, err := strconv.Atoi(chi.URLParam(r, "jobId"))
+	if err != nil {
+		return apperrors.NewValidationError("invalid jobId")
+	}
+	ra, err := requiredFloat(r, "ra")
+	if err != nil {
+		return err
+	}
+	dec, err := requiredFloat(r, "dec")
+	if err != nil {
+		return err
+	}
+	if ra < 0 || ra >= 360 {
+		return apperrors.NewValidationError("ra must be in [0, 360)")
+	}
+	if dec < -90 || dec > 90 {
+		return apperrors.NewValidationError("dec must be in [-90, 90]")
+	}
+
+	solution, err := c.service.Solution(r.Context(), jobID)
+	if err != nil {
+		return err
+	}
+	x, y, ok := solution.SkyToImage(ra, dec)
+	if !ok {
+		return apperrors.NewValidationError("position is more than 90 degrees from the field center")
+	}
+	httputil.WriteJSON(w, http.StatusOK, view.NewSkyPosition(x, y, ra, dec, solution.Contains(x, y)))
+	return nil
+}
+
+func requiredFloat(r *http.Request, key string) (float64, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return 0, apperrors.NewValidationError("missing " + key)
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, apperrors.NewValidationError("invalid " + key)
+	}
+	return v, nil
+}
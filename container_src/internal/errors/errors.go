@@ -8,6 +8,8 @@ import (
 var (
 	ErrNotFound     = errors.New("not found")
 	ErrInvalidInput = errors.New("invalid input")
+	ErrConflict     = errors.New("conflict")
+	ErrUnavailable  = errors.New("unavailable")
 	ErrExternalAPI  = errors.New("external API error")
 )
 
@@ -44,6 +46,30 @@ func NewValidationError(msg string) *APIError {
 	}
 }
 
+func NewTooLargeError(msg string) *APIError {
+	return &APIError{
+		Code:    413,
+		Message: msg,
+		Err:     ErrInvalidInput,
+	}
+}
+
+func NewConflictError(msg string) *APIError {
+	return &APIError{
+		Code:    409,
+		Message: msg,
+		Err:     ErrConflict,
+	}
+}
+
+func NewUnavailableError(msg string) *APIError {
+	return &APIError{
+		Code:    503,
+		Message: msg,
+		Err:     ErrUnavailable,
+	}
+}
+
 func NewExternalError(service string, err error) *APIError {
 	return &APIError{
 		Code:    502,
@@ -0,0 +1,38 @@
+package ingest
+
+import "bytes"
+
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatTIFF Format = "tiff"
+	FormatFITS Format = "fits"
+)
+
+var (
+	jpegMagic     = []byte{0xFF, 0xD8, 0xFF}
+	pngMagic      = []byte("\x89PNG\r\n\x1a\n")
+	tiffMagicLE   = []byte("II*\x00")
+	tiffMagicBE   = []byte("MM\x00*")
+	fitsMagic     = []byte("SIMPLE  =")
+	fitsMinLength = 2880
+)
+
+// Sniff identifies the image format from its leading bytes, ignoring the
+// filename and any declared content type.
+func Sniff(data []byte) (Format, bool) {
+	switch {
+	case bytes.HasPrefix(data, jpegMagic):
+		return FormatJPEG, true
+	case bytes.HasPrefix(data, pngMagic):
+		return FormatPNG, true
+	case bytes.HasPrefix(data, tiffMagicLE), bytes.HasPrefix(data, tiffMagicBE):
+		return FormatTIFF, true
+	case bytes.HasPrefix(data, fitsMagic) && len(data) >= fitsMinLength:
+		return FormatFITS, true
+	default:
+		return "", false
+	}
+}
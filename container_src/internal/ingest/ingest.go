@@ -0,0 +1,328 @@
+// Package ingest validates uploaded images and prepares them for solving.
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"server/internal/wcs"
+)
+
+const (
+	jpegQuality = 92
+	tempPattern = "upload-*"
+	// maxFITSHeader bounds how far into a FITS file the END card of the
+	// primary header is looked for.
+	maxFITSHeader  = 100 * 2880
+	fitsCardLength = 80
+)
+
+// ValidationError reports an upload that is not an image the solver can
+// accept.
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+func invalid(format string, args ...any) error {
+	return &ValidationError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Image is an upload after ingest. Body is a temporary file holding what
+// gets sent to the solver, positioned at its start; Close removes it.
+// Width and Height describe Body, while OriginalWidth and OriginalHeight
+// describe the image as the user sees it, before any downsampling.
+type Image struct {
+	Body           *os.File
+	Format         Format
+	Width          int
+	Height         int
+	OriginalWidth  int
+	OriginalHeight int
+	Orientation    int
+	Metadata       Metadata
+	// Downsample is the factor by which the image is too large for
+	// MaxEdge but could not be resampled here (TIFF and FITS). The solver
+	// is asked to downsample instead.
+	Downsample float64
+}
+
+// Close removes the image's temporary file.
+func (img *Image) Close() error {
+	return discard(img.Body)
+}
+
+func discard(f *os.File) error {
+	return errors.Join(f.Close(), os.Remove(f.Name()))
+}
+
+type Processor struct {
+	maxEdge   int
+	maxPixels int
+}
+
+// NewProcessor returns a Processor that shrinks images whose longer edge
+// exceeds maxEdge pixels and rejects images of more than maxPixels pixels
+// before decoding them. Zero disables either limit.
+func NewProcessor(maxEdge, maxPixels int) *Processor {
+	return &Processor{maxEdge: max(maxEdge, 0), maxPixels: max(maxPixels, 0)}
+}
+
+// checkPixels rejects an image whose declared size would take too much
+// memory to decode. A small, highly compressed file can declare enormous
+// dimensions, so this is checked from the header alone.
+func (p *Processor) checkPixels(w, h int) error {
+	if p.maxPixels > 0 && int64(w)*int64(h) > int64(p.maxPixels) {
+		return invalid("image is %dx%d pixels, more than the limit of %d", w, h, p.maxPixels)
+	}
+	return nil
+}
+
+// Process reads an upload, checks its real format, extracts its EXIF or
+// FITS metadata and normalises it. The upload is streamed to a temporary
+// file; only the headers and metadata segments are held in memory, and
+// the pixels only when they have to be decoded. It is spooled in full
+// rather than passed on as it arrives because the caller hashes it
+// before uploading, Sanitize edits it in place and a retried upload
+// sends it again. JPEG and PNG images are
+// rotated according to their EXIF orientation and downsampled if needed;
+// TIFF and FITS images are passed through as-is. Private metadata is
+// stripped from Body after it has been read, see Sanitize.
+func (p *Processor) Process(r io.Reader) (*Image, error) {
+	f, err := os.CreateTemp("", tempPattern)
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	img, err := p.process(f, r)
+	if err != nil {
+		_ = discard(f)
+		return nil, err
+	}
+	if _, err := img.Body.Seek(0, io.SeekStart); err != nil {
+		_ = img.Close()
+		return nil, fmt.Errorf("rewind image: %w", err)
+	}
+	return img, nil
+}
+
+func (p *Processor) process(f *os.File, r io.Reader) (*Image, error) {
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+	if size == 0 {
+		return nil, invalid("image is empty")
+	}
+
+	header := make([]byte, min(size, int64(fitsMinLength)))
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+	format, ok := Sniff(header)
+	if !ok {
+		return nil, invalid("unsupported image format: expected JPEG, PNG, TIFF or FITS")
+	}
+
+	switch format {
+	case FormatJPEG, FormatPNG:
+		return p.processRaster(f, size, format)
+	case FormatTIFF:
+		w, h, meta, err := readTIFF(f, size)
+		if err != nil {
+			return nil, invalid("cannot read %s image: %v", format, err)
+		}
+		return p.passThrough(f, format, w, h, meta)
+	default:
+		w, h, meta, err := readFITS(f, size)
+		if err != nil {
+			return nil, invalid("cannot read %s image: %v", format, err)
+		}
+		return p.passThrough(f, format, w, h, meta)
+	}
+}
+
+// processRaster works on the spooled file f. When the image has to be
+// rotated or downsampled it is re-encoded into a new temporary file and
+// f is removed.
+func (p *Processor) processRaster(f *os.File, size int64, format Format) (*Image, error) {
+	cfg, _, err := image.DecodeConfig(io.NewSectionReader(f, 0, size))
+	if err != nil {
+		return nil, invalid("cannot decode %s image: %v", format, err)
+	}
+	if err := p.checkPixels(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+
+	orientation := 1
+	var meta Metadata
+	if format == FormatJPEG {
+		if t, ok := jpegEXIF(f, size); ok {
+			orientation = exifOrientation(t)
+			meta = exifMetadata(t)
+		}
+	}
+	img := &Image{
+		Body:           f,
+		Format:         format,
+		Width:          cfg.Width,
+		Height:         cfg.Height,
+		OriginalWidth:  cfg.Width,
+		OriginalHeight: cfg.Height,
+		Orientation:    orientation,
+		Metadata:       meta,
+	}
+	if orientation >= 5 {
+		img.OriginalWidth, img.OriginalHeight = cfg.Height, cfg.Width
+	}
+
+	tooLarge := p.maxEdge > 0 && max(cfg.Width, cfg.Height) > p.maxEdge
+	if orientation == 1 && !tooLarge {
+		if err := Sanitize(f, format); err != nil {
+			return nil, fmt.Errorf("sanitize image: %w", err)
+		}
+		return img, nil
+	}
+
+	decoded, _, err := image.Decode(io.NewSectionReader(f, 0, size))
+	if err != nil {
+		return nil, invalid("cannot decode %s image: %v", format, err)
+	}
+	decoded = orient(downsample(decoded, p.maxEdge), orientation)
+
+	out, err := os.CreateTemp("", tempPattern)
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	if format == FormatPNG {
+		err = png.Encode(out, decoded)
+	} else {
+		err = jpeg.Encode(out, decoded, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		_ = discard(out)
+		return nil, fmt.Errorf("encode %s image: %w", format, err)
+	}
+	if err := discard(f); err != nil {
+		log.Printf("remove spooled upload: %v", err)
+	}
+
+	// Re-encoding writes no metadata, so there is nothing to sanitise.
+	bounds := decoded.Bounds()
+	img.Body = out
+	img.Width, img.Height = bounds.Dx(), bounds.Dy()
+	img.Orientation = 1
+	return img, nil
+}
+
+func (p *Processor) passThrough(f *os.File, format Format, w, h int, meta Metadata) (*Image, error) {
+	if err := p.checkPixels(w, h); err != nil {
+		return nil, err
+	}
+	if err := Sanitize(f, format); err != nil {
+		return nil, fmt.Errorf("sanitize image: %w", err)
+	}
+	img := &Image{
+		Body:           f,
+		Format:         format,
+		Width:          w,
+		Height:         h,
+		OriginalWidth:  w,
+		OriginalHeight: h,
+		Orientation:    1,
+		Metadata:       meta,
+	}
+	if longest := max(w, h); p.maxEdge > 0 && longest > p.maxEdge {
+		img.Downsample = float64(longest) / float64(p.maxEdge)
+	}
+	return img, nil
+}
+
+// exifOrientation returns the EXIF orientation, or 1 if there is none.
+func exifOrientation(t *tiff) int {
+	entries, _, err := t.readIFD(t.ifd0)
+	if err != nil {
+		return 1
+	}
+	e, ok := findEntry(entries, tagOrientation)
+	if !ok {
+		return 1
+	}
+	v, ok := t.uint(e)
+	if !ok || v < 1 || v > 8 {
+		return 1
+	}
+	return int(v)
+}
+
+// readTIFF reads the dimensions and EXIF metadata of a TIFF file from its
+// first directory.
+func readTIFF(r storage, size int64) (int, int, Metadata, error) {
+	t, err := parseTIFF(r, size)
+	if err != nil {
+		return 0, 0, Metadata{}, err
+	}
+	entries, _, err := t.readIFD(t.ifd0)
+	if err != nil {
+		return 0, 0, Metadata{}, err
+	}
+	we, okW := findEntry(entries, tagImageWidth)
+	he, okH := findEntry(entries, tagImageLength)
+	if !okW || !okH {
+		return 0, 0, Metadata{}, errors.New("missing image dimensions")
+	}
+	w, okW := t.uint(we)
+	h, okH := t.uint(he)
+	if !okW || !okH || w == 0 || h == 0 {
+		return 0, 0, Metadata{}, errors.New("invalid image dimensions")
+	}
+	return int(w), int(h), exifMetadata(t), nil
+}
+
+// readFITS reads the dimensions and metadata of a FITS file from its
+// primary header.
+func readFITS(r io.ReaderAt, size int64) (int, int, Metadata, error) {
+	data, err := readFITSHeader(r, size)
+	if err != nil {
+		return 0, 0, Metadata{}, err
+	}
+	header, err := wcs.ParseHeader(data)
+	if err != nil {
+		return 0, 0, Metadata{}, err
+	}
+	naxis, _ := header.Int("NAXIS")
+	w, okW := header.Int("NAXIS1")
+	h, okH := header.Int("NAXIS2")
+	if naxis < 2 || !okW || !okH || w <= 0 || h <= 0 {
+		return 0, 0, Metadata{}, errors.New("primary HDU is not a 2-D image")
+	}
+	return w, h, fitsMetadata(header), nil
+}
+
+// readFITSHeader returns the blocks of the primary header, up to and
+// including the one holding the END card.
+func readFITSHeader(r io.ReaderAt, size int64) ([]byte, error) {
+	var header []byte
+	block := make([]byte, fitsMinLength)
+	for off := int64(0); off+int64(len(block)) <= size && off < maxFITSHeader; off += int64(len(block)) {
+		if _, err := r.ReadAt(block, off); err != nil {
+			return nil, err
+		}
+		header = append(header, block...)
+		for card := 0; card < len(block); card += fitsCardLength {
+			if strings.TrimSpace(string(block[card:card+8])) == "END" {
+				return header, nil
+			}
+		}
+	}
+	return nil, errors.New("primary header has no END card")
+}
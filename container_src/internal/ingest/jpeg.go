@@ -0,0 +1,84 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// jpegSegment is a marker segment. start is the offset of the 0xFF marker
+// byte; size is the length of the payload after the two length bytes.
+type jpegSegment struct {
+	marker byte
+	start  int64
+	size   int64
+}
+
+func (s jpegSegment) end() int64 {
+	return s.start + 4 + s.size
+}
+
+// payload reads the segment's payload, which is at most 64 KiB.
+func (s jpegSegment) payload(r io.ReaderAt) ([]byte, error) {
+	data := make([]byte, s.size)
+	if _, err := r.ReadAt(data, s.start+4); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// jpegSegments lists the marker segments that precede the image data.
+// Walking stops at the start-of-scan marker, so only segment headers are
+// read.
+func jpegSegments(r io.ReaderAt, size int64) []jpegSegment {
+	var segments []jpegSegment
+	var head [4]byte
+	pos := int64(2)
+	for pos+4 <= size {
+		if _, err := r.ReadAt(head[:], pos); err != nil || head[0] != 0xFF {
+			break
+		}
+		marker := head[1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 {
+			pos += 2
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		length := int64(binary.BigEndian.Uint16(head[2:]))
+		if length < 2 || pos+2+length > size {
+			break
+		}
+		segments = append(segments, jpegSegment{marker: marker, start: pos, size: length - 2})
+		pos += 2 + length
+	}
+	return segments
+}
+
+// jpegEXIF returns the TIFF structure inside a JPEG's EXIF APP1 segment,
+// read into memory.
+func jpegEXIF(r io.ReaderAt, size int64) (*tiff, bool) {
+	for _, seg := range jpegSegments(r, size) {
+		if seg.marker != 0xE1 {
+			continue
+		}
+		data, err := seg.payload(r)
+		if err != nil || !bytes.HasPrefix(data, exifHeader) {
+			continue
+		}
+		exif := data[len(exifHeader):]
+		t, err := parseTIFF(memory(exif), int64(len(exif)))
+		if err != nil {
+			return nil, false
+		}
+		return t, true
+	}
+	return nil, false
+}
@@ -0,0 +1,235 @@
+package ingest
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/wcs"
+)
+
+// Metadata is what the upload says about how it was taken, read from EXIF
+// for camera images and from the primary header for FITS. Fields the file
+// does not carry are left empty. The observer's location and the capture
+// time are read for deriving hints only; Sanitize removes them from the
+// file itself.
+type Metadata struct {
+	CameraMake  string
+	CameraModel string
+	// FocalLength is in millimetres; FocalLength35mm is the equivalent
+	// focal length on a full-frame sensor.
+	FocalLength     *float64
+	FocalLength35mm *float64
+	// PixelSize is the sensor pixel pitch in micrometres, for the
+	// original, undownsampled image.
+	PixelSize *float64
+	TakenAt   *time.Time
+	Latitude  *float64
+	Longitude *float64
+	// RA and Dec are the telescope pointing in degrees.
+	RA  *float64
+	Dec *float64
+}
+
+func exifMetadata(t *tiff) Metadata {
+	var m Metadata
+	ifd0, _, err := t.readIFD(t.ifd0)
+	if err != nil {
+		return m
+	}
+	if e, ok := findEntry(ifd0, tagMake); ok {
+		m.CameraMake = t.ascii(e)
+	}
+	if e, ok := findEntry(ifd0, tagModel); ok {
+		m.CameraModel = t.ascii(e)
+	}
+	if exif, ok := t.subIFD(ifd0, tagExifIFD); ok {
+		m.readExif(t, exif)
+	}
+	if gps, ok := t.subIFD(ifd0, tagGPSIFD); ok {
+		m.readGPS(t, gps)
+	}
+	return m
+}
+
+func (t *tiff) subIFD(entries []ifdEntry, tag uint16) ([]ifdEntry, bool) {
+	e, ok := findEntry(entries, tag)
+	if !ok {
+		return nil, false
+	}
+	off, ok := t.uint(e)
+	if !ok {
+		return nil, false
+	}
+	sub, _, err := t.readIFD(off)
+	return sub, err == nil
+}
+
+func (m *Metadata) readExif(t *tiff, entries []ifdEntry) {
+	if e, ok := findEntry(entries, tagFocalLength); ok {
+		if v := t.rationals(e); len(v) == 1 && v[0] > 0 {
+			m.FocalLength = &v[0]
+		}
+	}
+	if e, ok := findEntry(entries, tagFocalLength35mm); ok {
+		if v, ok := t.uint(e); ok && v > 0 {
+			f := float64(v)
+			m.FocalLength35mm = &f
+		}
+	}
+	if e, ok := findEntry(entries, tagFocalPlaneXRes); ok {
+		if v := t.rationals(e); len(v) == 1 && v[0] > 0 {
+			// Resolution is in pixels per unit: 2 is inches (the default),
+			// 3 centimetres, 4 millimetres and 5 micrometres.
+			unitMM := 25.4
+			if ue, ok := findEntry(entries, tagFocalPlaneResUnit); ok {
+				switch u, _ := t.uint(ue); u {
+				case 3:
+					unitMM = 10
+				case 4:
+					unitMM = 1
+				case 5:
+					unitMM = 0.001
+				}
+			}
+			size := unitMM / v[0] * 1000
+			m.PixelSize = &size
+		}
+	}
+	if m.TakenAt == nil {
+		if e, ok := findEntry(entries, tagDateTimeOriginal); ok {
+			offset := ""
+			if oe, ok := findEntry(entries, tagOffsetTimeOriginal); ok {
+				offset = t.ascii(oe)
+			}
+			m.TakenAt = parseExifTime(t.ascii(e), offset)
+		}
+	}
+}
+
+func (m *Metadata) readGPS(t *tiff, entries []ifdEntry) {
+	m.Latitude = gpsCoordinate(t, entries, tagGPSLatitude, tagGPSLatitudeRef, "S", 90)
+	m.Longitude = gpsCoordinate(t, entries, tagGPSLongitude, tagGPSLongitudeRef, "W", 180)
+
+	// GPS time is UTC, which makes it preferable to DateTimeOriginal.
+	de, okDate := findEntry(entries, tagGPSDateStamp)
+	te, okTime := findEntry(entries, tagGPSTimeStamp)
+	if !okDate || !okTime {
+		return
+	}
+	date, err := time.Parse("2006:01:02", t.ascii(de))
+	hms := t.rationals(te)
+	if err != nil || len(hms) != 3 {
+		return
+	}
+	at := date.Add(time.Duration((hms[0]*3600 + hms[1]*60 + hms[2]) * float64(time.Second)))
+	m.TakenAt = &at
+}
+
+func gpsCoordinate(t *tiff, entries []ifdEntry, tag, refTag uint16, negative string, limit float64) *float64 {
+	e, ok := findEntry(entries, tag)
+	if !ok {
+		return nil
+	}
+	dms := t.rationals(e)
+	if len(dms) != 3 {
+		return nil
+	}
+	v := dms[0] + dms[1]/60 + dms[2]/3600
+	if re, ok := findEntry(entries, refTag); ok && strings.EqualFold(t.ascii(re), negative) {
+		v = -v
+	}
+	if math.Abs(v) > limit {
+		return nil
+	}
+	return &v
+}
+
+// parseExifTime reads an EXIF timestamp. Without an offset the camera's
+// time zone is unknown, so no time is returned.
+func parseExifTime(value, offset string) *time.Time {
+	if offset == "" {
+		return nil
+	}
+	at, err := time.Parse("2006:01:02 15:04:05-07:00", value+offset)
+	if err != nil {
+		return nil
+	}
+	at = at.UTC()
+	return &at
+}
+
+// fitsMetadata reads the pointing, optics, site and observation time from
+// the keywords written by common capture software.
+func fitsMetadata(h wcs.Header) Metadata {
+	var m Metadata
+	m.CameraModel = h.String("INSTRUME")
+	if v, ok := h.Float("FOCALLEN"); ok && v > 0 {
+		m.FocalLength = &v
+	}
+	if v, ok := h.Float("XPIXSZ"); ok && v > 0 {
+		m.PixelSize = &v
+	}
+	if v, ok := h.Float("SITELAT"); ok {
+		m.Latitude = &v
+	}
+	if v, ok := h.Float("SITELONG"); ok {
+		m.Longitude = &v
+	}
+	m.RA = fitsAngle(h, 15, "RA", "OBJCTRA")
+	m.Dec = fitsAngle(h, 1, "DEC", "OBJCTDEC")
+	if m.RA == nil || m.Dec == nil {
+		m.RA, m.Dec = nil, nil
+	}
+	if v := h.String("DATE-OBS"); v != "" {
+		for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02T15:04:05", "2006-01-02"} {
+			if at, err := time.Parse(layout, v); err == nil {
+				m.TakenAt = &at
+				break
+			}
+		}
+	}
+	return m
+}
+
+// fitsAngle reads the first of keys as decimal degrees, or as a
+// sexagesimal string in units of sexagesimalScale degrees (15 for hours).
+func fitsAngle(h wcs.Header, sexagesimalScale float64, keys ...string) *float64 {
+	for _, key := range keys {
+		raw := h.String(key)
+		if raw == "" {
+			continue
+		}
+		if v, err := strconv.ParseFloat(raw, 64); err == nil {
+			return &v
+		}
+		if v, ok := parseSexagesimal(raw); ok {
+			v *= sexagesimalScale
+			return &v
+		}
+	}
+	return nil
+}
+
+func parseSexagesimal(s string) (float64, bool) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ':' || r == 'h' || r == 'm' || r == 's' || r == 'd'
+	})
+	if len(fields) == 0 || len(fields) > 3 {
+		return 0, false
+	}
+	sign := 1.0
+	if strings.HasPrefix(fields[0], "-") {
+		sign = -1
+	}
+	var v float64
+	for i, f := range fields {
+		n, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return 0, false
+		}
+		v += math.Abs(n) / math.Pow(60, float64(i))
+	}
+	return sign * v, true
+}
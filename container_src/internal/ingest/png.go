@@ -0,0 +1,56 @@
+package ingest
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// pngChunk is a chunk of a PNG file. start is the offset of its length
+// field; size is the length of its data, which is followed by a CRC.
+type pngChunk struct {
+	typ   string
+	start int64
+	size  int64
+}
+
+func (c pngChunk) end() int64 {
+	return c.start + 12 + c.size
+}
+
+// pngChunks lists the chunks of a PNG file up to and including IEND,
+// reading only their headers.
+func pngChunks(r io.ReaderAt, size int64) []pngChunk {
+	var chunks []pngChunk
+	var head [8]byte
+	pos := int64(len(pngMagic))
+	for pos+12 <= size {
+		if _, err := r.ReadAt(head[:], pos); err != nil {
+			break
+		}
+		c := pngChunk{typ: string(head[4:]), start: pos, size: int64(binary.BigEndian.Uint32(head[:4]))}
+		if c.end() > size {
+			break
+		}
+		chunks = append(chunks, c)
+		if c.typ == "IEND" {
+			break
+		}
+		pos = c.end()
+	}
+	return chunks
+}
+
+// keyword reads the keyword that starts a text chunk, which is at most 79
+// bytes and ends with a NUL.
+func (c pngChunk) keyword(r io.ReaderAt) string {
+	buf := make([]byte, min(c.size, 80))
+	if _, err := r.ReadAt(buf, c.start+8); err != nil {
+		return ""
+	}
+	for i, b := range buf {
+		if b == 0 {
+			return string(buf[:i])
+		}
+	}
+	return ""
+}
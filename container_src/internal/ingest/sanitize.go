@@ -0,0 +1,262 @@
+package ingest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	tagXMP                = 0x02BC
+	tagMakerNote          = 0x927C
+	tagCameraOwnerName    = 0xA430
+	tagBodySerialNumber   = 0xA431
+	tagLensSerialNumber   = 0xA435
+	tagCameraSerialNumber = 0xC62F
+)
+
+// privateTags are removed wherever they appear. TIFF files keep their
+// XMP packet in a tag, which can repeat the GPS fields as text. The GPS
+// directory is handled separately since its contents have to go as well.
+var privateTags = []uint16{
+	tagXMP,
+	tagMakerNote,
+	tagCameraOwnerName,
+	tagBodySerialNumber,
+	tagLensSerialNumber,
+	tagCameraSerialNumber,
+}
+
+var (
+	xmpHeader          = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmpExtensionHeader = []byte("http://ns.adobe.com/xmp/extension/\x00")
+)
+
+// pngXMPKeyword marks a PNG text chunk holding an XMP packet.
+const pngXMPKeyword = "XML:com.adobe.xmp"
+
+// fitsLocationKeys are the FITS keywords that record where an image was
+// taken.
+var fitsLocationKeys = []string{
+	"SITELAT", "SITELONG", "LAT-OBS", "LONG-OBS",
+	"OBSGEO-B", "OBSGEO-L", "OBSGEO-X", "OBSGEO-Y", "OBSGEO-Z",
+}
+
+// Sanitize removes location, maker note and serial number metadata from
+// an image file in place. EXIF tags are dropped from their directories
+// and their values zeroed and FITS location cards are blanked, so the
+// image data is untouched. XMP packets and PNG EXIF chunks are cut out
+// and the file shrinks accordingly.
+func Sanitize(f *os.File, format Format) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatJPEG:
+		return sanitizeJPEG(f, info.Size())
+	case FormatPNG:
+		return sanitizePNG(f, info.Size())
+	case FormatTIFF:
+		if t, err := parseTIFF(f, info.Size()); err == nil {
+			return t.sanitize()
+		}
+	case FormatFITS:
+		return sanitizeFITS(f, info.Size())
+	}
+	return nil
+}
+
+// sanitizeJPEG scrubs the EXIF segment and drops XMP packets, including
+// the extension segments of packets too large for one segment, since
+// they can repeat the same fields as text. Only the segments themselves
+// are read into memory.
+func sanitizeJPEG(f *os.File, size int64) error {
+	var xmp []span
+	for _, seg := range jpegSegments(f, size) {
+		if seg.marker != 0xE1 {
+			continue
+		}
+		data, err := seg.payload(f)
+		if err != nil {
+			return fmt.Errorf("read jpeg segment: %w", err)
+		}
+		switch {
+		case bytes.HasPrefix(data, exifHeader):
+			exif := data[len(exifHeader):]
+			t, err := parseTIFF(memory(exif), int64(len(exif)))
+			if err != nil {
+				continue
+			}
+			if err := t.sanitize(); err != nil {
+				return err
+			}
+			if _, err := f.WriteAt(data, seg.start+4); err != nil {
+				return fmt.Errorf("write jpeg segment: %w", err)
+			}
+		case bytes.HasPrefix(data, xmpHeader), bytes.HasPrefix(data, xmpExtensionHeader):
+			xmp = append(xmp, span{seg.start, seg.end()})
+		}
+	}
+	return cut(f, size, xmp)
+}
+
+// sanitizePNG drops eXIf chunks, which carry the same EXIF structure as
+// a JPEG, and text chunks holding XMP. Nothing else in a PNG records
+// where it was taken.
+func sanitizePNG(f *os.File, size int64) error {
+	var private []span
+	for _, c := range pngChunks(f, size) {
+		text := c.typ == "iTXt" || c.typ == "tEXt" || c.typ == "zTXt"
+		if c.typ == "eXIf" || text && c.keyword(f) == pngXMPKeyword {
+			private = append(private, span{c.start, c.end()})
+		}
+	}
+	return cut(f, size, private)
+}
+
+// sanitizeFITS blanks the primary header cards that give the observing
+// site. A blank card is valid commentary, so the header keeps its size.
+func sanitizeFITS(f *os.File, size int64) error {
+	header, err := readFITSHeader(f, size)
+	if err != nil {
+		return nil
+	}
+	blank := bytes.Repeat([]byte(" "), fitsCardLength)
+	for off := 0; off+fitsCardLength <= len(header); off += fitsCardLength {
+		key := strings.TrimSpace(string(header[off : off+8]))
+		if !slices.Contains(fitsLocationKeys, key) {
+			continue
+		}
+		if _, err := f.WriteAt(blank, int64(off)); err != nil {
+			return fmt.Errorf("write fits header: %w", err)
+		}
+	}
+	return nil
+}
+
+// span is the byte range [start, end) of a file.
+type span struct {
+	start, end int64
+}
+
+// cut removes spans, which must be in order and not overlap, from a file
+// of the given size by moving the rest of it down and truncating it.
+func cut(f *os.File, size int64, spans []span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	dst := spans[0].start
+	for i, sp := range spans {
+		next := size
+		if i+1 < len(spans) {
+			next = spans[i+1].start
+		}
+		n, err := io.Copy(io.NewOffsetWriter(f, dst), io.NewSectionReader(f, sp.end, next-sp.end))
+		if err != nil {
+			return fmt.Errorf("remove metadata: %w", err)
+		}
+		dst += n
+	}
+	return f.Truncate(dst)
+}
+
+// sanitize walks every image directory and its EXIF sub-directory.
+// Directories that cannot be read are skipped; only failed writes are
+// reported.
+func (t *tiff) sanitize() error {
+	seen := make(map[uint32]bool)
+	for off := t.ifd0; off != 0 && !seen[off]; {
+		seen[off] = true
+		entries, next, err := t.readIFD(off)
+		if err != nil {
+			return nil
+		}
+		if exif, ok := findEntry(entries, tagExifIFD); ok {
+			if exifOff, ok := t.uint(exif); ok && !seen[exifOff] {
+				seen[exifOff] = true
+				err := t.removeEntries(exifOff, func(e ifdEntry) bool {
+					return slices.Contains(privateTags, e.tag)
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if gps, ok := findEntry(entries, tagGPSIFD); ok {
+			if gpsOff, ok := t.uint(gps); ok {
+				if err := t.zeroIFD(gpsOff); err != nil {
+					return err
+				}
+			}
+		}
+		err = t.removeEntries(off, func(e ifdEntry) bool {
+			return e.tag == tagGPSIFD || slices.Contains(privateTags, e.tag)
+		})
+		if err != nil {
+			return err
+		}
+		off = next
+	}
+	return nil
+}
+
+// removeEntries drops the matching entries from the directory at off,
+// zeroing their values. The remaining entries move up and the freed
+// space at the end of the directory is cleared.
+func (t *tiff) removeEntries(off uint32, remove func(ifdEntry) bool) error {
+	entries, next, err := t.readIFD(off)
+	if err != nil {
+		return nil
+	}
+	kept := make([]ifdEntry, 0, len(entries))
+	for _, e := range entries {
+		if remove(e) {
+			if err := t.zeroValue(e); err != nil {
+				return err
+			}
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if len(kept) == len(entries) {
+		return nil
+	}
+
+	dir := make([]byte, 2+len(entries)*12+4)
+	t.order.PutUint16(dir, uint16(len(kept)))
+	pos := 2
+	for _, e := range kept {
+		copy(dir[pos:], e.raw[:])
+		pos += 12
+	}
+	t.order.PutUint32(dir[pos:], next)
+	return t.write(uint64(off), dir)
+}
+
+// zeroIFD clears a directory that is no longer referenced, including the
+// values it points to.
+func (t *tiff) zeroIFD(off uint32) error {
+	entries, _, err := t.readIFD(off)
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		if err := t.zeroValue(e); err != nil {
+			return err
+		}
+	}
+	return t.zero(uint64(off), 2+uint64(len(entries))*12+4)
+}
+
+func (t *tiff) zeroValue(e ifdEntry) error {
+	if off, n, ok := t.valueRange(e); ok && n > 4 {
+		if err := t.zero(off, n); err != nil {
+			return err
+		}
+	}
+	return t.zero(uint64(e.pos)+8, 4)
+}
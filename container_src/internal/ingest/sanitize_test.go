@@ -0,0 +1,189 @@
+package ingest
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// private is what the fixtures in testdata record about where and with
+// what an image was taken, none of which may reach the solver.
+var private = []string{
+	"MAKERNOTE-SECRET",
+	"Jane Observer",
+	"BODY-SN-",
+	"LENS-SN-",
+	"DNG-SN-",
+	"GPS-AREA-GREENWICH",
+	"GPSLatitude",
+	"GPSLongitude",
+	"SITELAT",
+	"SITELONG",
+	"LAT-OBS",
+	"LONG-OBS",
+	"OBSGEO",
+	"51.47779",
+}
+
+func TestProcessStripsPrivateMetadata(t *testing.T) {
+	tests := []struct {
+		file   string
+		format Format
+		// kept is metadata that has to survive sanitising.
+		kept string
+		// located reports whether the metadata read before sanitising
+		// includes the observer's position.
+		located bool
+		pixels  func(t *testing.T, data []byte) []byte
+	}{
+		{"photo.jpg", FormatJPEG, "Canon EOS 6D", true, jpegScan},
+		{"photo.png", FormatPNG, "Orion from the back garden", false, pngImageData},
+		{"image.tiff", FormatTIFF, "Canon EOS 6D", true, tiffStrip},
+		{"image.fits", FormatFITS, "ZWO ASI294MC Pro", true, fitsData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			original, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.ContainsFunc(private, func(s string) bool { return bytes.Contains(original, []byte(s)) }) {
+				t.Fatal("fixture carries no private metadata")
+			}
+
+			img, out := process(t, original)
+			if img.Format != tt.format {
+				t.Errorf("format = %s, want %s", img.Format, tt.format)
+			}
+			for _, s := range private {
+				if bytes.Contains(out, []byte(s)) {
+					t.Errorf("%q left in the sanitised image", s)
+				}
+			}
+			if !bytes.Contains(out, []byte(tt.kept)) {
+				t.Errorf("%q removed from the sanitised image", tt.kept)
+			}
+			if located := img.Metadata.Latitude != nil && img.Metadata.TakenAt != nil; located != tt.located {
+				t.Errorf("location read = %v, want %v", located, tt.located)
+			}
+			if !bytes.Equal(tt.pixels(t, out), tt.pixels(t, original)) {
+				t.Error("image data changed")
+			}
+			if tt.format == FormatJPEG || tt.format == FormatTIFF {
+				checkEXIF(t, tt.format, out)
+			}
+
+			again, _ := process(t, out)
+			if again.Width != img.Width || again.Height != img.Height {
+				t.Errorf("sanitised image is %dx%d, want %dx%d", again.Width, again.Height, img.Width, img.Height)
+			}
+		})
+	}
+}
+
+func process(t *testing.T, data []byte) (*Image, []byte) {
+	t.Helper()
+	img, err := NewProcessor(0, 0).Process(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	t.Cleanup(func() { _ = img.Close() })
+	out, err := io.ReadAll(img.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img, out
+}
+
+// checkEXIF checks that the GPS directory and private tags are no longer
+// referenced, while the rest of the EXIF data still reads.
+func checkEXIF(t *testing.T, format Format, data []byte) {
+	t.Helper()
+	var tf *tiff
+	if format == FormatJPEG {
+		var ok bool
+		if tf, ok = jpegEXIF(memory(data), int64(len(data))); !ok {
+			t.Fatal("EXIF segment lost")
+		}
+	} else {
+		var err error
+		if tf, err = parseTIFF(memory(data), int64(len(data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ifd0, _, err := tf.readIFD(tf.ifd0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := findEntry(ifd0, tagGPSIFD); ok {
+		t.Error("GPS directory still referenced")
+	}
+	exif, ok := tf.subIFD(ifd0, tagExifIFD)
+	if !ok {
+		t.Fatal("EXIF directory lost")
+	}
+	for _, e := range slices.Concat(ifd0, exif) {
+		if slices.Contains(privateTags, e.tag) {
+			t.Errorf("tag %#04x still present", e.tag)
+		}
+	}
+	if _, ok := findEntry(exif, tagFocalLength); !ok {
+		t.Error("focal length removed")
+	}
+}
+
+func jpegScan(t *testing.T, data []byte) []byte {
+	segments := jpegSegments(memory(data), int64(len(data)))
+	if len(segments) == 0 {
+		t.Fatal("no JPEG segments")
+	}
+	return data[segments[len(segments)-1].end():]
+}
+
+func pngImageData(t *testing.T, data []byte) []byte {
+	var idat []byte
+	for _, c := range pngChunks(memory(data), int64(len(data))) {
+		if c.typ == "IDAT" {
+			idat = append(idat, data[c.start:c.end()]...)
+		}
+	}
+	if idat == nil {
+		t.Fatal("no IDAT chunks")
+	}
+	return idat
+}
+
+func tiffStrip(t *testing.T, data []byte) []byte {
+	const (
+		tagStripOffsets    = 0x0111
+		tagStripByteCounts = 0x0117
+	)
+	tf, err := parseTIFF(memory(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _, err := tf.readIFD(tf.ifd0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oe, _ := findEntry(entries, tagStripOffsets)
+	ne, _ := findEntry(entries, tagStripByteCounts)
+	off, okOff := tf.uint(oe)
+	n, okN := tf.uint(ne)
+	if !okOff || !okN || int(off+n) > len(data) {
+		t.Fatal("no image strip")
+	}
+	return data[off : off+n]
+}
+
+func fitsData(t *testing.T, data []byte) []byte {
+	header, err := readFITSHeader(memory(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data[len(header):]
+}
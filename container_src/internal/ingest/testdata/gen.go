@@ -0,0 +1,276 @@
+//go:build ignore
+
+// gen writes the sanitiser fixtures: a JPEG, PNG, TIFF and FITS image
+// carrying the location, maker note, serial number and XMP metadata that
+// Sanitize has to remove. Run it from this directory with go run gen.go.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	width  = 64
+	height = 48
+)
+
+// xmp is an XMP packet recording where the image was taken.
+const xmp = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+	`<rdf:Description xmlns:exif="http://ns.adobe.com/exif/1.0/" exif:GPSLatitude="51,28.67N" ` +
+	`exif:GPSLongitude="0,0.08W"/></rdf:RDF></x:xmpmeta>`
+
+func main() {
+	write("photo.jpg", photoJPEG())
+	write("photo.png", photoPNG())
+	write("image.tiff", imageTIFF())
+	write("image.fits", imageFITS())
+}
+
+func write(name string, data []byte) {
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func gradient() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.SetGray(x, y, color.Gray{Y: uint8(x*4 + y)})
+		}
+	}
+	return img
+}
+
+// entry is a TIFF directory entry whose value is already encoded.
+type entry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+	// sub is written as a directory of its own, and value is its offset.
+	sub []entry
+}
+
+type tiffWriter struct {
+	order interface {
+		binary.ByteOrder
+		binary.AppendByteOrder
+	}
+	buf []byte
+}
+
+func (w *tiffWriter) ascii(tag uint16, s string) entry {
+	return entry{tag: tag, typ: 2, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func (w *tiffWriter) undefined(tag uint16, data []byte) entry {
+	return entry{tag: tag, typ: 7, count: uint32(len(data)), value: data}
+}
+
+func (w *tiffWriter) short(tag uint16, v uint16) entry {
+	return entry{tag: tag, typ: 3, count: 1, value: w.order.AppendUint16(nil, v)}
+}
+
+func (w *tiffWriter) long(tag uint16, v uint32) entry {
+	return entry{tag: tag, typ: 4, count: 1, value: w.order.AppendUint32(nil, v)}
+}
+
+func (w *tiffWriter) rationals(tag uint16, v ...uint32) entry {
+	var data []byte
+	for i := 0; i < len(v); i += 2 {
+		data = w.order.AppendUint32(data, v[i])
+		data = w.order.AppendUint32(data, v[i+1])
+	}
+	return entry{tag: tag, typ: 5, count: uint32(len(v) / 2), value: data}
+}
+
+// ifd appends a directory, the values that do not fit in its entries and
+// its sub-directories, and returns its offset.
+func (w *tiffWriter) ifd(entries []entry) uint32 {
+	slices.SortFunc(entries, func(a, b entry) int { return int(a.tag) - int(b.tag) })
+	off := len(w.buf)
+	w.buf = append(w.buf, make([]byte, 2+len(entries)*12+4)...)
+	w.order.PutUint16(w.buf[off:], uint16(len(entries)))
+	for i, e := range entries {
+		if e.sub != nil {
+			e.typ, e.count = 4, 1
+			e.value = w.order.AppendUint32(nil, w.ifd(e.sub))
+		}
+		pos := off + 2 + i*12
+		w.order.PutUint16(w.buf[pos:], e.tag)
+		w.order.PutUint16(w.buf[pos+2:], e.typ)
+		w.order.PutUint32(w.buf[pos+4:], e.count)
+		if len(e.value) <= 4 {
+			copy(w.buf[pos+8:], e.value)
+			continue
+		}
+		w.order.PutUint32(w.buf[pos+8:], uint32(len(w.buf)))
+		w.buf = append(w.buf, e.value...)
+		if len(w.buf)%2 == 1 {
+			w.buf = append(w.buf, 0)
+		}
+	}
+	return uint32(off)
+}
+
+// cameraEntries are the EXIF tags of a camera image: make and model,
+// optics and capture time, which are kept, and a maker note, serial
+// numbers, an owner and a GPS fix, which are not.
+func cameraEntries(w *tiffWriter) []entry {
+	return []entry{
+		w.ascii(0x010F, "Canon"),
+		w.ascii(0x0110, "Canon EOS 6D"),
+		w.short(0x0112, 1),
+		{tag: 0x8769, sub: []entry{
+			w.ascii(0x9003, "2024:03:10 21:30:00"),
+			w.ascii(0x9011, "+01:00"),
+			w.rationals(0x920A, 50, 1),
+			w.undefined(0x927C, []byte("MAKERNOTE-SECRET-0123456789")),
+			w.ascii(0xA430, "Jane Observer"),
+			w.ascii(0xA431, "BODY-SN-483920"),
+			w.ascii(0xA435, "LENS-SN-771204"),
+		}},
+		{tag: 0x8825, sub: []entry{
+			w.ascii(0x01, "N"),
+			w.rationals(0x02, 51, 1, 28, 1, 4020, 100),
+			w.ascii(0x03, "W"),
+			w.rationals(0x04, 0, 1, 0, 1, 501, 100),
+			w.rationals(0x07, 20, 1, 30, 1, 0, 1),
+			w.undefined(0x1C, []byte("GPS-AREA-GREENWICH")),
+			w.ascii(0x1D, "2024:03:10"),
+		}},
+	}
+}
+
+func exif() []byte {
+	w := &tiffWriter{order: binary.LittleEndian, buf: []byte("II*\x00\x08\x00\x00\x00")}
+	w.ifd(cameraEntries(w))
+	return w.buf
+}
+
+func photoJPEG() []byte {
+	var scan bytes.Buffer
+	if err := jpeg.Encode(&scan, gradient(), &jpeg.Options{Quality: 90}); err != nil {
+		log.Fatal(err)
+	}
+
+	extended := []byte("http://ns.adobe.com/xmp/extension/\x00")
+	extended = append(extended, strings.Repeat("0", 32)...)
+	extended = binary.BigEndian.AppendUint32(extended, uint32(len(xmp)))
+	extended = binary.BigEndian.AppendUint32(extended, 0)
+	extended = append(extended, xmp...)
+
+	out := []byte{0xFF, 0xD8}
+	out = appendSegment(out, 0xE1, append([]byte("Exif\x00\x00"), exif()...))
+	out = appendSegment(out, 0xE1, append([]byte("http://ns.adobe.com/xap/1.0/\x00"), xmp...))
+	out = appendSegment(out, 0xE1, extended)
+	return append(out, scan.Bytes()[2:]...)
+}
+
+func appendSegment(out []byte, marker byte, payload []byte) []byte {
+	out = append(out, 0xFF, marker)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	return append(out, payload...)
+}
+
+func photoPNG() []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gradient()); err != nil {
+		log.Fatal(err)
+	}
+	encoded := buf.Bytes()
+	// The signature and IHDR come first; the metadata follows them.
+	ihdrEnd := 8 + 12 + int(binary.BigEndian.Uint32(encoded[8:]))
+
+	out := slices.Clone(encoded[:ihdrEnd])
+	out = appendChunk(out, "eXIf", exif())
+	out = appendChunk(out, "iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"+xmp))
+	out = appendChunk(out, "tEXt", []byte("Comment\x00Orion from the back garden"))
+	return append(out, encoded[ihdrEnd:]...)
+}
+
+func appendChunk(out []byte, typ string, data []byte) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+	start := len(out)
+	out = append(out, typ...)
+	out = append(out, data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[start:]))
+}
+
+// imageTIFF writes a big-endian, uncompressed 8-bit TIFF with its pixels
+// in one strip straight after the header, and the camera tags plus an
+// XMP packet and a DNG camera serial number in its first directory.
+func imageTIFF() []byte {
+	pixels := gradient().Pix
+	w := &tiffWriter{order: binary.BigEndian, buf: []byte("MM\x00*\x00\x00\x00\x00")}
+	w.buf = append(w.buf, pixels...)
+	entries := append(cameraEntries(w),
+		w.long(0x0100, width),
+		w.long(0x0101, height),
+		w.short(0x0102, 8),
+		w.short(0x0103, 1),
+		w.short(0x0106, 1),
+		w.long(0x0111, 8),
+		w.long(0x0116, height),
+		w.long(0x0117, uint32(len(pixels))),
+		w.undefined(0x02BC, []byte(xmp)),
+		w.ascii(0xC62F, "DNG-SN-99812"),
+	)
+	ifd0 := w.ifd(entries)
+	w.order.PutUint32(w.buf[4:], ifd0)
+	return w.buf
+}
+
+// imageFITS writes an 8-bit FITS image whose header records the pointing
+// and optics, which are kept, and the observing site, which is not.
+func imageFITS() []byte {
+	cards := []string{
+		"SIMPLE  =                    T",
+		"BITPIX  =                    8",
+		"NAXIS   =                    2",
+		fmt.Sprintf("NAXIS1  = %20d", width),
+		fmt.Sprintf("NAXIS2  = %20d", height),
+		"INSTRUME= 'ZWO ASI294MC Pro'",
+		"FOCALLEN=                 400.",
+		"XPIXSZ  =                 4.63",
+		"RA      =              83.8221",
+		"DEC     =              -5.3911",
+		"DATE-OBS= '2024-03-10T20:30:00'",
+		"SITELAT =             51.47779 / observatory latitude",
+		"SITELONG=             -0.00139 / observatory longitude",
+		"LAT-OBS =             51.47779",
+		"LONG-OBS=             -0.00139",
+		"OBSGEO-B=             51.47779",
+		"OBSGEO-L=             -0.00139",
+		"OBSGEO-X=           3980608.90",
+		"OBSGEO-Y=             -102.475",
+		"OBSGEO-Z=           4966861.27",
+		"END",
+	}
+	var out []byte
+	for _, card := range cards {
+		out = append(out, fmt.Sprintf("%-80s", card)...)
+	}
+	out = pad(out, ' ')
+	out = append(out, gradient().Pix...)
+	return pad(out, 0)
+}
+
+func pad(data []byte, fill byte) []byte {
+	for len(data)%2880 != 0 {
+		data = append(data, fill)
+	}
+	return data
+}
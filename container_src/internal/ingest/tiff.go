@@ -0,0 +1,278 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TIFF tag types, see TIFF 6.0 section 2.
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeUndefined = 7
+	typeSLong     = 9
+	typeSRational = 10
+)
+
+var typeSizes = map[uint16]uint32{
+	typeByte: 1, typeASCII: 1, typeShort: 2, typeLong: 4, typeRational: 8,
+	typeUndefined: 1, typeSLong: 4, typeSRational: 8,
+}
+
+const (
+	tagImageWidth  = 0x0100
+	tagImageLength = 0x0101
+	tagMake        = 0x010F
+	tagModel       = 0x0110
+	tagOrientation = 0x0112
+	tagExifIFD     = 0x8769
+	tagGPSIFD      = 0x8825
+
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagFocalLength        = 0x920A
+	tagFocalPlaneXRes     = 0xA20E
+	tagFocalPlaneResUnit  = 0xA210
+	tagFocalLength35mm    = 0xA405
+
+	tagGPSLatitudeRef  = 0x01
+	tagGPSLatitude     = 0x02
+	tagGPSLongitudeRef = 0x03
+	tagGPSLongitude    = 0x04
+	tagGPSTimeStamp    = 0x07
+	tagGPSDateStamp    = 0x1D
+)
+
+// tiff is a TIFF structure, either a whole TIFF file or the payload of a
+// JPEG EXIF segment. Offsets are relative to the start of s.
+type tiff struct {
+	s     storage
+	size  uint64
+	order binary.ByteOrder
+	ifd0  uint32
+}
+
+// storage holds a tiff: the spooled upload for a TIFF file, or a copy of
+// the segment in memory for a JPEG. Sanitising writes to it in place.
+type storage interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// memory is a storage backed by a byte slice.
+type memory []byte
+
+func (m memory) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 || off >= int64(len(m)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m memory) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 || off+int64(len(p)) > int64(len(m)) {
+		return 0, errors.New("write out of range")
+	}
+	return copy(m[off:], p), nil
+}
+
+// maxValueSize bounds the tag values read into memory. The tags worth
+// reading are a few bytes long; larger values such as maker notes are
+// only ever zeroed.
+const maxValueSize = 64 << 10
+
+type ifdEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	// pos is the offset of the 12-byte entry and raw its contents.
+	pos uint32
+	raw [12]byte
+}
+
+func parseTIFF(s storage, size int64) (*tiff, error) {
+	var head [8]byte
+	if size < int64(len(head)) {
+		return nil, errors.New("tiff header too short")
+	}
+	if _, err := s.ReadAt(head[:], 0); err != nil {
+		return nil, fmt.Errorf("read tiff header: %w", err)
+	}
+	var order binary.ByteOrder
+	switch string(head[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid tiff byte order")
+	}
+	if order.Uint16(head[2:4]) != 42 {
+		return nil, errors.New("invalid tiff magic")
+	}
+	return &tiff{s: s, size: uint64(size), order: order, ifd0: order.Uint32(head[4:8])}, nil
+}
+
+// read returns the n bytes at off, or false if they are out of range.
+func (t *tiff) read(off, n uint64) ([]byte, bool) {
+	if off+n > t.size {
+		return nil, false
+	}
+	buf := make([]byte, n)
+	if _, err := t.s.ReadAt(buf, int64(off)); err != nil {
+		return nil, false
+	}
+	return buf, true
+}
+
+func (t *tiff) write(off uint64, p []byte) error {
+	if _, err := t.s.WriteAt(p, int64(off)); err != nil {
+		return fmt.Errorf("write tiff: %w", err)
+	}
+	return nil
+}
+
+// zero clears n bytes at off, a chunk at a time since the value being
+// cleared can be large.
+func (t *tiff) zero(off, n uint64) error {
+	chunk := make([]byte, min(n, 32<<10))
+	for n > 0 {
+		k := min(n, uint64(len(chunk)))
+		if err := t.write(off, chunk[:k]); err != nil {
+			return err
+		}
+		off += k
+		n -= k
+	}
+	return nil
+}
+
+// readIFD returns the entries of the directory at off and the offset of
+// the next directory, or zero if there is none.
+func (t *tiff) readIFD(off uint32) ([]ifdEntry, uint32, error) {
+	head, ok := t.read(uint64(off), 2)
+	if off == 0 || !ok {
+		return nil, 0, fmt.Errorf("ifd offset %d out of range", off)
+	}
+	n := uint32(t.order.Uint16(head))
+	raw, ok := t.read(uint64(off)+2, uint64(n)*12+4)
+	if !ok {
+		return nil, 0, fmt.Errorf("ifd at %d truncated", off)
+	}
+
+	entries := make([]ifdEntry, n)
+	for i := range n {
+		e := ifdEntry{pos: off + 2 + i*12}
+		copy(e.raw[:], raw[i*12:])
+		e.tag = t.order.Uint16(e.raw[0:])
+		e.typ = t.order.Uint16(e.raw[2:])
+		e.count = t.order.Uint32(e.raw[4:])
+		entries[i] = e
+	}
+	return entries, t.order.Uint32(raw[n*12:]), nil
+}
+
+// valueRange returns where an entry's value is stored: inline in the
+// entry when it fits in four bytes, at an offset otherwise.
+func (t *tiff) valueRange(e ifdEntry) (off, n uint64, ok bool) {
+	size, ok := typeSizes[e.typ]
+	if !ok {
+		return 0, 0, false
+	}
+	n = uint64(size) * uint64(e.count)
+	if n <= 4 {
+		return uint64(e.pos) + 8, n, true
+	}
+	off = uint64(t.order.Uint32(e.raw[8:]))
+	if off+n > t.size {
+		return 0, 0, false
+	}
+	return off, n, true
+}
+
+// value returns the raw bytes of an entry's value, if it is small enough
+// to be worth reading.
+func (t *tiff) value(e ifdEntry) ([]byte, bool) {
+	off, n, ok := t.valueRange(e)
+	if !ok || n > maxValueSize {
+		return nil, false
+	}
+	if n <= 4 {
+		return e.raw[8 : 8+n], true
+	}
+	return t.read(off, n)
+}
+
+func (t *tiff) uint(e ifdEntry) (uint32, bool) {
+	v, ok := t.value(e)
+	if !ok || len(v) == 0 {
+		return 0, false
+	}
+	switch e.typ {
+	case typeByte, typeUndefined:
+		return uint32(v[0]), true
+	case typeShort:
+		return uint32(t.order.Uint16(v)), true
+	case typeLong, typeSLong:
+		return t.order.Uint32(v), true
+	}
+	return 0, false
+}
+
+func findEntry(entries []ifdEntry, tag uint16) (ifdEntry, bool) {
+	for _, e := range entries {
+		if e.tag == tag {
+			return e, true
+		}
+	}
+	return ifdEntry{}, false
+}
+
+func (t *tiff) ascii(e ifdEntry) string {
+	if e.typ != typeASCII {
+		return ""
+	}
+	v, ok := t.value(e)
+	if !ok {
+		return ""
+	}
+	if i := bytes.IndexByte(v, 0); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(string(v))
+}
+
+// rationals returns the values of a RATIONAL or SRATIONAL entry.
+func (t *tiff) rationals(e ifdEntry) []float64 {
+	if e.typ != typeRational && e.typ != typeSRational {
+		return nil
+	}
+	v, ok := t.value(e)
+	if !ok {
+		return nil
+	}
+	out := make([]float64, 0, e.count)
+	for i := 0; i+8 <= len(v); i += 8 {
+		num, den := t.order.Uint32(v[i:]), t.order.Uint32(v[i+4:])
+		if den == 0 {
+			return nil
+		}
+		if e.typ == typeSRational {
+			out = append(out, float64(int32(num))/float64(int32(den)))
+		} else {
+			out = append(out, float64(num)/float64(den))
+		}
+	}
+	return out
+}
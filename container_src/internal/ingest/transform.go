@@ -0,0 +1,95 @@
+package ingest
+
+import (
+	"image"
+	"image/color"
+)
+
+// downsample shrinks img with a box filter so that its longer
+// edge is at most maxEdge pixels. Each output pixel averages the source
+// pixels it covers.
+func downsample(img image.Image, maxEdge int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	longest := max(w, h)
+	if maxEdge <= 0 || longest <= maxEdge {
+		return img
+	}
+	dw := max(w*maxEdge/longest, 1)
+	dh := max(h*maxEdge/longest, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := range dh {
+		y0 := b.Min.Y + dy*h/dh
+		y1 := max(b.Min.Y+(dy+1)*h/dh, y0+1)
+		for dx := range dw {
+			x0 := b.Min.X + dx*w/dw
+			x1 := max(b.Min.X+(dx+1)*w/dw, x0+1)
+
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := img.At(x, y).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(dx, dy, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// orient applies an EXIF orientation (1-8) so the pixels are laid out as
+// the image is meant to be displayed.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := range dh {
+		for dx := range dw {
+			sx, sy := orientSource(orientation, dx, dy, w, h)
+			dst.Set(dx, dy, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// orientSource maps a pixel of the oriented image back to the stored image
+// of size w x h.
+func orientSource(orientation, dx, dy, w, h int) (int, int) {
+	switch orientation {
+	case 2: // mirrored horizontally
+		return w - 1 - dx, dy
+	case 3: // rotated 180
+		return w - 1 - dx, h - 1 - dy
+	case 4: // mirrored vertically
+		return dx, h - 1 - dy
+	case 5: // transposed
+		return dy, dx
+	case 6: // rotated 90 clockwise
+		return dy, h - 1 - dx
+	case 7: // transversed
+		return w - 1 - dy, h - 1 - dx
+	case 8: // rotated 90 counter-clockwise
+		return w - 1 - dy, dx
+	default:
+		return dx, dy
+	}
+}
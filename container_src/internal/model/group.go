@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// JobGroup ties together the jobs created by one batch submission.
+type JobGroup struct {
+	ID        string
+	CreatedAt time.Time
+	Children  []GroupChild
+}
+
+// GroupChild is one image of a batch. SubID is zero when the image could
+// not be submitted, in which case Error says why.
+type GroupChild struct {
+	Filename string
+	SubID    int
+	Error    string
+}
+
+// GroupResult is the current state of every job in a group, in the same
+// order as the group's children. Results[i] is nil for failed submissions
+// and for jobs whose status could not be read, in which case Errors[i]
+// says why.
+type GroupResult struct {
+	Group   *JobGroup
+	Results []*SolveResult
+	Errors  []string
+}
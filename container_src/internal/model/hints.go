@@ -0,0 +1,124 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ScaleUnits string
+
+const (
+	ScaleUnitsDegWidth     ScaleUnits = "degwidth"
+	ScaleUnitsArcminWidth  ScaleUnits = "arcminwidth"
+	ScaleUnitsArcsecPerPix ScaleUnits = "arcsecperpix"
+)
+
+// SolveHints are optional plate-solving parameters forwarded to Nova.
+// A nil field means "let the solver decide".
+type SolveHints struct {
+	ScaleUnits       ScaleUnits
+	ScaleLower       *float64
+	ScaleUpper       *float64
+	ScaleEst         *float64
+	ScaleErr         *float64
+	CenterRA         *float64
+	CenterDec        *float64
+	Radius           *float64
+	DownsampleFactor *float64
+	TweakOrder       *int
+	Parity           *int
+	UseSextractor    *bool
+	CrpixCenter      *bool
+}
+
+// Validate checks the hints against the ranges accepted by Nova.
+func (h SolveHints) Validate() error {
+	if err := h.validateScale(); err != nil {
+		return err
+	}
+	if err := h.validateCenter(); err != nil {
+		return err
+	}
+	if h.DownsampleFactor != nil && *h.DownsampleFactor < 1 {
+		return errors.New("downsample_factor must be at least 1")
+	}
+	if h.TweakOrder != nil && (*h.TweakOrder < 0 || *h.TweakOrder > 10) {
+		return errors.New("tweak_order must be between 0 and 10")
+	}
+	if h.Parity != nil && (*h.Parity < 0 || *h.Parity > 2) {
+		return errors.New("parity must be 0, 1 or 2")
+	}
+	return nil
+}
+
+func (h SolveHints) validateScale() error {
+	hasBounds := h.ScaleLower != nil || h.ScaleUpper != nil
+	hasEstimate := h.ScaleEst != nil || h.ScaleErr != nil
+
+	if h.ScaleUnits == "" {
+		if hasBounds || hasEstimate {
+			return errors.New("scale_units is required with scale hints")
+		}
+		return nil
+	}
+
+	switch h.ScaleUnits {
+	case ScaleUnitsDegWidth, ScaleUnitsArcminWidth, ScaleUnitsArcsecPerPix:
+	default:
+		return fmt.Errorf("scale_units must be one of %s, %s, %s",
+			ScaleUnitsDegWidth, ScaleUnitsArcminWidth, ScaleUnitsArcsecPerPix)
+	}
+
+	switch {
+	case hasBounds && hasEstimate:
+		return errors.New("use either scale_lower/scale_upper or scale_est/scale_err, not both")
+	case hasBounds:
+		if h.ScaleLower == nil || h.ScaleUpper == nil {
+			return errors.New("scale_lower and scale_upper must be given together")
+		}
+		if *h.ScaleLower <= 0 || *h.ScaleUpper <= 0 {
+			return errors.New("scale bounds must be positive")
+		}
+		if *h.ScaleLower > *h.ScaleUpper {
+			return errors.New("scale_lower must not exceed scale_upper")
+		}
+	case hasEstimate:
+		if h.ScaleEst == nil || h.ScaleErr == nil {
+			return errors.New("scale_est and scale_err must be given together")
+		}
+		if *h.ScaleEst <= 0 {
+			return errors.New("scale_est must be positive")
+		}
+		if *h.ScaleErr <= 0 || *h.ScaleErr > 100 {
+			return errors.New("scale_err must be a percentage between 0 and 100")
+		}
+	default:
+		return errors.New("scale_units requires scale_lower/scale_upper or scale_est/scale_err")
+	}
+	return nil
+}
+
+func (h SolveHints) validateCenter() error {
+	if h.CenterRA == nil && h.CenterDec == nil {
+		if h.Radius != nil {
+			return errors.New("radius requires center_ra and center_dec")
+		}
+		return nil
+	}
+	if h.CenterRA == nil || h.CenterDec == nil {
+		return errors.New("center_ra and center_dec must be given together")
+	}
+	if *h.CenterRA < 0 || *h.CenterRA >= 360 {
+		return errors.New("center_ra must be in [0, 360)")
+	}
+	if *h.CenterDec < -90 || *h.CenterDec > 90 {
+		return errors.New("center_dec must be in [-90, 90]")
+	}
+	if h.Radius == nil {
+		return errors.New("radius is required with center_ra and center_dec")
+	}
+	if *h.Radius <= 0 || *h.Radius > 180 {
+		return errors.New("radius must be in (0, 180]")
+	}
+	return nil
+}
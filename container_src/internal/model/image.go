@@ -0,0 +1,22 @@
+package model
+
+// ImageInfo describes an uploaded image after ingest. Width and Height
+// are the dimensions sent to the solver; OriginalWidth and OriginalHeight
+// are those of the upload as displayed, before any downsampling, which
+// pixel coordinates in results refer to.
+type ImageInfo struct {
+	Format         string
+	Width          int
+	Height         int
+	OriginalWidth  int
+	OriginalHeight int
+}
+
+// Scale returns how many original pixels one solved pixel spans along
+// each axis, 1 when the image was not downsampled.
+func (i *ImageInfo) Scale() (sx, sy float64) {
+	if i == nil || i.Width <= 0 || i.Height <= 0 || i.OriginalWidth <= 0 || i.OriginalHeight <= 0 {
+		return 1, 1
+	}
+	return float64(i.OriginalWidth) / float64(i.Width), float64(i.OriginalHeight) / float64(i.Height)
+}
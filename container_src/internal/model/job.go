@@ -0,0 +1,94 @@
+package model
+
+import "time"
+
+// IsTerminal reports whether no further transitions are expected.
+func (s JobStatus) IsTerminal() bool {
+	return s == StatusSuccess || s == StatusFailure || s == StatusCancelled
+}
+
+// SubmitOptions are the caller-supplied settings for a new submission.
+type SubmitOptions struct {
+	Hints       SolveHints
+	AutoHints   *SolveHints
+	CallbackURL string
+}
+
+// Submission is the outcome of accepting an image. Deduplicated is set
+// when an identical earlier submission was reused instead of uploading.
+type Submission struct {
+	SubID        int
+	Deduplicated bool
+	Status       JobStatus
+	// AutoHints are the hints derived from the image's metadata that were
+	// sent to Nova, if any.
+	AutoHints *SolveHints
+}
+
+type StatusTransition struct {
+	Status JobStatus
+	At     time.Time
+}
+
+// JobRecord is the server-side state kept for a submission, keyed by its
+// Nova submission ID.
+type JobRecord struct {
+	SubID       int
+	NovaJobID   int
+	Filename    string
+	SourceURL   string
+	Image       *ImageInfo
+	Hints       SolveHints
+	AutoHints   *SolveHints
+	Callback    *CallbackState
+	Status      JobStatus
+	History     []StatusTransition
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt *time.Time
+	Result      *SolveResult
+}
+
+// CallbackState tracks webhook delivery for a job. A record with
+// DeadLetteredAt set has exhausted its retries and will not be resent.
+type CallbackState struct {
+	URL            string
+	Attempts       int
+	LastError      string
+	DeliveredAt    *time.Time
+	DeadLetteredAt *time.Time
+}
+
+// Pending reports whether the webhook still has to be delivered.
+func (c *CallbackState) Pending() bool {
+	return c != nil && c.DeliveredAt == nil && c.DeadLetteredAt == nil
+}
+
+func NewJobRecord(subID int, createdAt time.Time) *JobRecord {
+	return &JobRecord{
+		SubID:     subID,
+		Status:    StatusQueued,
+		History:   []StatusTransition{{Status: StatusQueued, At: createdAt}},
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func (j *JobRecord) IsCancelled() bool {
+	return j.Status == StatusCancelled
+}
+
+// Transition moves the job to status, recording the change in its history.
+// It returns false if the job is already in that status or already terminal.
+func (j *JobRecord) Transition(status JobStatus, at time.Time) bool {
+	if j.Status == status || j.Status.IsTerminal() {
+		return false
+	}
+	j.Status = status
+	j.UpdatedAt = at
+	j.History = append(j.History, StatusTransition{Status: status, At: at})
+	if status.IsTerminal() {
+		j.CompletedAt = &at
+	}
+	return true
+}
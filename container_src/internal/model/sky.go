@@ -0,0 +1,31 @@
+package model
+
+// ConeQuery asks for the objects within Radius degrees of RA/Dec (J2000).
+// MaxMagnitude leaves out objects fainter in V or without a V magnitude;
+// Types, when set, keeps only objects whose ObjectType or
+// DeepSkyObjectType is listed. Offset and Limit select the page.
+type ConeQuery struct {
+	RA           float64
+	Dec          float64
+	Radius       float64
+	MaxMagnitude *float64
+	Types        []string
+	Offset       int
+	Limit        int
+}
+
+// ConeResult is one page of a cone search, brightest first. Total counts
+// every match; Truncated is set when the brightest objects alone filled
+// the search, so fainter ones may be missing.
+type ConeResult struct {
+	Objects   []NearbyObject
+	Total     int
+	Truncated bool
+}
+
+// NearbyObject is an object found by a cone search, Separation degrees from
+// the cone's center.
+type NearbyObject struct {
+	IdentifiedObject
+	Separation float64
+}
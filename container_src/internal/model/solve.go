@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type JobStatus string
 
 const (
@@ -28,6 +30,14 @@ const (
 	DSOSupernova       DeepSkyObjectType = "SUPERNOVA"
 )
 
+// PositionSource records how an object's pixel position was obtained.
+type PositionSource string
+
+const (
+	PositionAnnotation PositionSource = "annotation"
+	PositionProjected  PositionSource = "projected"
+)
+
 type SpectralClass string
 
 const (
@@ -41,16 +51,57 @@ const (
 )
 
 type IdentifiedObject struct {
-	Type            ObjectType
-	Identifier      string
-	Name            string
-	Constellation   *Constellation
-	XCoordinate     float64
-	YCoordinate     float64
-	VMagnitude      *float64
-	SpectralClass   SpectralClass
-	DistanceParsecs *float64
-	DSOType         DeepSkyObjectType
+	Type                 ObjectType
+	Identifier           string
+	Name                 string
+	Aliases              []string
+	RA                   *float64
+	Dec                  *float64
+	Constellation        *Constellation
+	XCoordinate          float64
+	YCoordinate          float64
+	PositionSource       PositionSource
+	VMagnitude           *float64
+	Magnitudes           map[string]float64
+	ColorIndexBV         *float64
+	SpectralClass        SpectralClass
+	DistanceParsecs      *float64
+	DistanceErrorParsecs *float64
+	ProperMotion         *ProperMotion
+	RadialVelocity       *float64
+	Redshift             *float64
+	DSOType              DeepSkyObjectType
+	Size                 *AngularSize
+	// Sources names the catalog each field came from; see catalog.Object.
+	Sources map[string]string
+}
+
+// ProperMotion is in mas/yr; RA includes the cos(Dec) factor.
+type ProperMotion struct {
+	RA  float64
+	Dec float64
+}
+
+// AngularSize gives a deep-sky object's axes in arcmin and the major
+// axis' position angle in degrees east of north.
+type AngularSize struct {
+	MajorAxis     *float64
+	MinorAxis     *float64
+	PositionAngle *float64
+}
+
+// Calibration describes the solved field: its center and radius in degrees,
+// the plate scale in arcsec/pixel, the angle of north measured east of up
+// in degrees, and the image parity (1 when flipped relative to the sky).
+type Calibration struct {
+	RA           float64
+	Dec          float64
+	Radius       float64
+	PixScale     float64
+	Orientation  float64
+	Parity       float64
+	WidthArcsec  float64
+	HeightArcsec float64
 }
 
 type SolveResult struct {
@@ -58,5 +109,19 @@ type SolveResult struct {
 	Status            JobStatus
 	AnnotatedImageURL string
 	Objects           []IdentifiedObject
+	Calibration       *Calibration
+	Image             *ImageInfo
+	AutoHints         *SolveHints
 	NovaJobID         int
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	CompletedAt       *time.Time
+	History           []StatusTransition
+	Callback          *CallbackState
+}
+
+// IsFinal reports whether the result is a recorded terminal status.
+// Transient upstream errors are reported as FAILURE without a completion time.
+func (r *SolveResult) IsFinal() bool {
+	return r != nil && r.Status.IsTerminal() && r.CompletedAt != nil
 }
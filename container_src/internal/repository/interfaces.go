@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"server/internal/model"
+)
+
+// JobRepository persists job records across requests. PendingCallbacks
+// lists the jobs whose webhook has been neither delivered nor
+// dead-lettered, so deliveries can resume after a restart.
+type JobRepository interface {
+	Get(ctx context.Context, subID int) (*model.JobRecord, bool, error)
+	Save(ctx context.Context, job *model.JobRecord) error
+	PendingCallbacks(ctx context.Context) ([]*model.JobRecord, error)
+}
+
+// GroupRepository persists batch submission groups.
+type GroupRepository interface {
+	GetGroup(ctx context.Context, id string) (*model.JobGroup, bool, error)
+	SaveGroup(ctx context.Context, group *model.JobGroup) error
+}
+
+// UploadRepository maps the content hash of a submission to its job.
+type UploadRepository interface {
+	FindUpload(ctx context.Context, hash string) (int, bool, error)
+	SaveUpload(ctx context.Context, hash string, subID int) error
+}
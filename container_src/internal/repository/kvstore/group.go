@@ -0,0 +1,44 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"server/internal/client/kv"
+	"server/internal/model"
+	"server/internal/repository"
+)
+
+const groupKeyPrefix = "group:"
+
+var _ repository.GroupRepository = (*GroupRepository)(nil)
+
+type GroupRepository struct {
+	kv  kv.Client
+	ttl int
+}
+
+func NewGroupRepository(kvClient kv.Client, ttlSeconds int) *GroupRepository {
+	return &GroupRepository{kv: kvClient, ttl: ttlSeconds}
+}
+
+func (r *GroupRepository) GetGroup(ctx context.Context, id string) (*model.JobGroup, bool, error) {
+	data, found, err := r.kv.Get(ctx, groupKeyPrefix+id)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	var group model.JobGroup
+	if err := json.Unmarshal(data, &group); err != nil {
+		return nil, false, fmt.Errorf("decode group %s: %w", id, err)
+	}
+	return &group, true, nil
+}
+
+func (r *GroupRepository) SaveGroup(ctx context.Context, group *model.JobGroup) error {
+	data, err := json.Marshal(group)
+	if err != nil {
+		return fmt.Errorf("encode group %s: %w", group.ID, err)
+	}
+	return r.kv.Put(ctx, groupKeyPrefix+group.ID, data, r.ttl)
+}
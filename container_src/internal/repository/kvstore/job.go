@@ -0,0 +1,94 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"server/internal/client/kv"
+	"server/internal/model"
+	"server/internal/repository"
+)
+
+var _ repository.JobRepository = (*JobRepository)(nil)
+
+const (
+	jobKeyPrefix      = "job:"
+	callbackKeyPrefix = "callback:"
+)
+
+type JobRepository struct {
+	kv  kv.Client
+	ttl int
+}
+
+func NewJobRepository(kvClient kv.Client, ttlSeconds int) *JobRepository {
+	return &JobRepository{kv: kvClient, ttl: ttlSeconds}
+}
+
+func (r *JobRepository) Get(ctx context.Context, subID int) (*model.JobRecord, bool, error) {
+	data, found, err := r.kv.Get(ctx, jobKey(subID))
+	if err != nil || !found {
+		return nil, false, err
+	}
+	var job model.JobRecord
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, false, fmt.Errorf("decode job %d: %w", subID, err)
+	}
+	return &job, true, nil
+}
+
+func (r *JobRepository) Save(ctx context.Context, job *model.JobRecord) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("encode job %d: %w", job.SubID, err)
+	}
+	if err := r.kv.Put(ctx, jobKey(job.SubID), data, r.ttl); err != nil {
+		return err
+	}
+	switch {
+	case job.Callback.Pending():
+		// KV cannot filter on values, so jobs awaiting a webhook are also
+		// indexed under their own prefix for PendingCallbacks to list.
+		return r.kv.Put(ctx, callbackKey(job.SubID), []byte(strconv.Itoa(job.SubID)), r.ttl)
+	case job.Callback != nil:
+		// Delivered or dead-lettered: the job leaves the index.
+		return r.kv.Delete(ctx, callbackKey(job.SubID))
+	}
+	return nil
+}
+
+// PendingCallbacks lists the indexed jobs and keeps those still pending.
+// Jobs leave the index when their webhook is delivered or dead-lettered,
+// so an entry is only stale if that save failed.
+func (r *JobRepository) PendingCallbacks(ctx context.Context) ([]*model.JobRecord, error) {
+	keys, err := r.kv.List(ctx, callbackKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var pending []*model.JobRecord
+	for _, key := range keys {
+		subID, err := strconv.Atoi(strings.TrimPrefix(key, callbackKeyPrefix))
+		if err != nil {
+			continue
+		}
+		job, found, err := r.Get(ctx, subID)
+		if err != nil {
+			return nil, err
+		}
+		if found && job.Callback.Pending() {
+			pending = append(pending, job)
+		}
+	}
+	return pending, nil
+}
+
+func jobKey(subID int) string {
+	return fmt.Sprintf("%s%d", jobKeyPrefix, subID)
+}
+
+func callbackKey(subID int) string {
+	return fmt.Sprintf("%s%d", callbackKeyPrefix, subID)
+}
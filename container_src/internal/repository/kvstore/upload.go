@@ -0,0 +1,39 @@
+package kvstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"server/internal/client/kv"
+	"server/internal/repository"
+)
+
+const uploadKeyPrefix = "upload:"
+
+var _ repository.UploadRepository = (*UploadRepository)(nil)
+
+type UploadRepository struct {
+	kv  kv.Client
+	ttl int
+}
+
+func NewUploadRepository(kvClient kv.Client, ttlSeconds int) *UploadRepository {
+	return &UploadRepository{kv: kvClient, ttl: ttlSeconds}
+}
+
+func (r *UploadRepository) FindUpload(ctx context.Context, hash string) (int, bool, error) {
+	data, found, err := r.kv.Get(ctx, uploadKeyPrefix+hash)
+	if err != nil || !found {
+		return 0, false, err
+	}
+	subID, err := strconv.Atoi(string(data))
+	if err != nil {
+		return 0, false, fmt.Errorf("decode upload %s: %w", hash, err)
+	}
+	return subID, true, nil
+}
+
+func (r *UploadRepository) SaveUpload(ctx context.Context, hash string, subID int) error {
+	return r.kv.Put(ctx, uploadKeyPrefix+hash, []byte(strconv.Itoa(subID)), r.ttl)
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"server/internal/model"
+	"server/internal/repository"
+)
+
+var _ repository.GroupRepository = (*GroupRepository)(nil)
+
+type GroupRepository struct {
+	mu     sync.RWMutex
+	groups map[string]model.JobGroup
+}
+
+func NewGroupRepository() *GroupRepository {
+	return &GroupRepository{groups: make(map[string]model.JobGroup)}
+}
+
+func (r *GroupRepository) GetGroup(_ context.Context, id string) (*model.JobGroup, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	group, ok := r.groups[id]
+	if !ok {
+		return nil, false, nil
+	}
+	group.Children = append([]model.GroupChild(nil), group.Children...)
+	return &group, true, nil
+}
+
+func (r *GroupRepository) SaveGroup(_ context.Context, group *model.JobGroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *group
+	stored.Children = append([]model.GroupChild(nil), group.Children...)
+	r.groups[group.ID] = stored
+	return nil
+}
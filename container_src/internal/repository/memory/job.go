@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"server/internal/model"
+	"server/internal/repository"
+)
+
+var _ repository.JobRepository = (*JobRepository)(nil)
+
+type JobRepository struct {
+	mu   sync.RWMutex
+	jobs map[int]model.JobRecord
+}
+
+func NewJobRepository() *JobRepository {
+	return &JobRepository{jobs: make(map[int]model.JobRecord)}
+}
+
+func (r *JobRepository) Get(_ context.Context, subID int) (*model.JobRecord, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[subID]
+	if !ok {
+		return nil, false, nil
+	}
+	return cloneJob(job), true, nil
+}
+
+func (r *JobRepository) Save(_ context.Context, job *model.JobRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.SubID] = *cloneJob(*job)
+	return nil
+}
+
+func (r *JobRepository) PendingCallbacks(_ context.Context) ([]*model.JobRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var pending []*model.JobRecord
+	for _, job := range r.jobs {
+		if job.Callback.Pending() {
+			pending = append(pending, cloneJob(job))
+		}
+	}
+	return pending, nil
+}
+
+// cloneJob copies the slices and pointers of a record so callers cannot
+// mutate the stored copy.
+func cloneJob(job model.JobRecord) *model.JobRecord {
+	job.History = append([]model.StatusTransition(nil), job.History...)
+	if job.Callback != nil {
+		callback := *job.Callback
+		job.Callback = &callback
+	}
+	if job.Result != nil {
+		result := *job.Result
+		result.Objects = append([]model.IdentifiedObject(nil), result.Objects...)
+		job.Result = &result
+	}
+	return &job
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"server/internal/repository"
+)
+
+var _ repository.UploadRepository = (*UploadRepository)(nil)
+
+type UploadRepository struct {
+	mu      sync.RWMutex
+	uploads map[string]int
+}
+
+func NewUploadRepository() *UploadRepository {
+	return &UploadRepository{uploads: make(map[string]int)}
+}
+
+func (r *UploadRepository) FindUpload(_ context.Context, hash string) (int, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subID, ok := r.uploads[hash]
+	return subID, ok, nil
+}
+
+func (r *UploadRepository) SaveUpload(_ context.Context, hash string, subID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.uploads[hash] = subID
+	return nil
+}
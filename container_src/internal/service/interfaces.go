@@ -5,9 +5,34 @@ import (
 	"io"
 
 	"server/internal/model"
+	"server/internal/wcs"
 )
 
+// Upload is one image of a batch submission.
+type Upload struct {
+	File     io.Reader
+	Filename string
+}
+
 type SolveService interface {
-	Submit(ctx context.Context, file io.Reader, filename string) (int, error)
+	Submit(ctx context.Context, file io.Reader, filename string, opts model.SubmitOptions) (model.Submission, error)
+	SubmitURL(ctx context.Context, imageURL string, opts model.SubmitOptions) (model.Submission, error)
 	GetStatus(ctx context.Context, subID int, fetch bool) (*model.SolveResult, error)
+	Cancel(ctx context.Context, subID int) error
+	Subscribe(ctx context.Context, subID int) (<-chan *model.SolveResult, error)
+	Solution(ctx context.Context, subID int) (*wcs.WCS, error)
+	SubmitBatch(ctx context.Context, uploads []Upload, opts model.SubmitOptions) (*model.JobGroup, error)
+	GetBatch(ctx context.Context, groupID string, fetch bool) (*model.GroupResult, error)
+}
+
+// ObjectService looks up objects by name or position, outside of a solve.
+type ObjectService interface {
+	Lookup(ctx context.Context, name string) (*model.IdentifiedObject, error)
+	ConeSearch(ctx context.Context, q model.ConeQuery) (*model.ConeResult, error)
+}
+
+// CompletionNotifier delivers a finished job to its callback URL and
+// reports how many attempts it took.
+type CompletionNotifier interface {
+	Notify(ctx context.Context, callbackURL string, result *model.SolveResult) (int, error)
 }
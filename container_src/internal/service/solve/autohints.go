@@ -0,0 +1,172 @@
+package solve
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"server/internal/ingest"
+	"server/internal/model"
+)
+
+const (
+	arcsecPerRadian = 206264.806
+
+	// fullFrameDiagonal is the diagonal of a 36x24 mm sensor, which
+	// defines 35mm-equivalent focal lengths.
+	fullFrameDiagonal = 43.267
+
+	// Relative uncertainty of each way of estimating the pixel scale.
+	pixelPitchTolerance  = 0.1
+	sensorTableTolerance = 0.15
+	equivalentTolerance  = 0.2
+	// pointingRadius is used with a FITS pointing when the field size is
+	// unknown.
+	pointingRadius = 10
+
+	// zenithGrid is the spacing in degrees that a zenith computed from
+	// the observer's position is snapped to, so the hint sent to Nova
+	// only places them within a cell some 1,500 km across. zenithRadius
+	// covers the sky above the horizon from anywhere in that cell.
+	zenithGrid   = 15
+	zenithRadius = 100
+)
+
+// sensorWidths maps camera models to the width of their sensor's long
+// edge in millimetres, for images that give a focal length but neither a
+// pixel pitch nor a 35mm equivalent.
+var sensorWidths = map[string]float64{
+	"canon eos 6d":         35.8,
+	"canon eos 6d mark ii": 35.9,
+	"canon eos r":          36.0,
+	"canon eos ra":         36.0,
+	"canon eos r6":         35.9,
+	"canon eos 800d":       22.3,
+	"canon eos 250d":       22.3,
+	"nikon d810":           35.9,
+	"nikon d850":           35.9,
+	"nikon z 6":            35.9,
+	"ilce-7m3":             35.6,
+	"ilce-7sm3":            35.6,
+}
+
+// deriveHints estimates the pixel scale and field center from the
+// image's metadata. The returned hints only set what could be derived.
+func deriveHints(img *ingest.Image) model.SolveHints {
+	var h model.SolveHints
+	meta := img.Metadata
+
+	longEdge := float64(max(img.Width, img.Height))
+	if longEdge == 0 {
+		return h
+	}
+	// Pixel pitch refers to the original image; after downsampling each
+	// uploaded pixel covers more sky.
+	binning := float64(max(img.OriginalWidth, img.OriginalHeight)) / longEdge
+
+	var scale, tolerance float64
+	switch {
+	case meta.PixelSize != nil && meta.FocalLength != nil:
+		scale = arcsecPerRadian * *meta.PixelSize / 1000 / *meta.FocalLength * binning
+		tolerance = pixelPitchTolerance
+	case meta.FocalLength35mm != nil:
+		diagonal := math.Hypot(float64(img.Width), float64(img.Height))
+		fov := 2 * math.Atan(fullFrameDiagonal/2 / *meta.FocalLength35mm)
+		scale = fov * arcsecPerRadian / diagonal
+		tolerance = equivalentTolerance
+	case meta.FocalLength != nil:
+		width, ok := sensorWidths[strings.ToLower(strings.TrimSpace(meta.CameraModel))]
+		if !ok {
+			break
+		}
+		scale = arcsecPerRadian * width / *meta.FocalLength / longEdge
+		tolerance = sensorTableTolerance
+	}
+	if scale > 0 && !math.IsInf(scale, 0) {
+		h.ScaleUnits = model.ScaleUnitsArcsecPerPix
+		h.ScaleLower = ptr(scale * (1 - tolerance))
+		h.ScaleUpper = ptr(scale * (1 + tolerance))
+	}
+
+	switch {
+	case meta.RA != nil && meta.Dec != nil && math.Abs(*meta.Dec) <= 90:
+		radius := float64(pointingRadius)
+		if scale > 0 {
+			// Allow for the pointing being off by a full field.
+			diagonal := math.Hypot(float64(img.Width), float64(img.Height))
+			radius = min(max(scale*diagonal/3600, 1), 180)
+		}
+		h.CenterRA = ptr(normalizeRA(*meta.RA))
+		h.CenterDec = ptr(*meta.Dec)
+		h.Radius = ptr(radius)
+	case meta.Latitude != nil && meta.Longitude != nil && meta.TakenAt != nil:
+		// The zenith would give the observer's position away, since the
+		// capture time stays in the image, so only a coarse one is used.
+		h.CenterRA = ptr(normalizeRA(snap(localSiderealTime(*meta.TakenAt, *meta.Longitude), zenithGrid)))
+		h.CenterDec = ptr(snap(*meta.Latitude, zenithGrid))
+		h.Radius = ptr(float64(zenithRadius))
+	}
+
+	if img.Downsample > 1 {
+		h.DownsampleFactor = ptr(math.Ceil(img.Downsample))
+	}
+	return h
+}
+
+// applyDerivedHints fills in hints the caller did not give. Scale and
+// center are taken as a whole, so a partial explicit hint is never mixed
+// with a derived one. It returns the derived hints that were applied, or
+// nil if none were.
+func applyDerivedHints(hints *model.SolveHints, derived model.SolveHints) *model.SolveHints {
+	var applied model.SolveHints
+	used := false
+
+	if hints.ScaleUnits == "" && derived.ScaleUnits != "" {
+		hints.ScaleUnits, hints.ScaleLower, hints.ScaleUpper = derived.ScaleUnits, derived.ScaleLower, derived.ScaleUpper
+		applied.ScaleUnits, applied.ScaleLower, applied.ScaleUpper = derived.ScaleUnits, derived.ScaleLower, derived.ScaleUpper
+		used = true
+	}
+	if hints.CenterRA == nil && hints.CenterDec == nil && hints.Radius == nil && derived.CenterRA != nil {
+		hints.CenterRA, hints.CenterDec, hints.Radius = derived.CenterRA, derived.CenterDec, derived.Radius
+		applied.CenterRA, applied.CenterDec, applied.Radius = derived.CenterRA, derived.CenterDec, derived.Radius
+		used = true
+	}
+	if hints.DownsampleFactor == nil && derived.DownsampleFactor != nil {
+		hints.DownsampleFactor = derived.DownsampleFactor
+		applied.DownsampleFactor = derived.DownsampleFactor
+		used = true
+	}
+
+	if !used {
+		return nil
+	}
+	return &applied
+}
+
+// localSiderealTime returns the right ascension of the meridian, in
+// degrees, at the given longitude and time.
+func localSiderealTime(at time.Time, longitude float64) float64 {
+	jd := float64(at.UnixNano())/float64(24*time.Hour) + 2440587.5
+	gmst := 280.46061837 + 360.98564736629*(jd-2451545.0)
+	return normalizeRA(gmst + longitude)
+}
+
+// snap rounds v to the nearest multiple of grid.
+func snap(v, grid float64) float64 {
+	return math.Round(v/grid) * grid
+}
+
+func normalizeRA(ra float64) float64 {
+	ra = math.Mod(ra, 360)
+	if ra < 0 {
+		ra += 360
+	}
+	if ra >= 360 {
+		ra = 0
+	}
+	return ra
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
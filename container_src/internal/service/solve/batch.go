@@ -0,0 +1,117 @@
+package solve
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	apperrors "server/internal/errors"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+// SubmitBatch uploads every image as its own job, at most
+// batchConcurrency at a time, and records them under one group. A failed
+// image does not stop the others; it is recorded on its child entry.
+func (s *Service) SubmitBatch(ctx context.Context, uploads []service.Upload, opts model.SubmitOptions) (*model.JobGroup, error) {
+	if err := s.checkCallback(opts); err != nil {
+		return nil, err
+	}
+
+	group := &model.JobGroup{
+		ID:        rand.Text(),
+		CreatedAt: time.Now(),
+		Children:  make([]model.GroupChild, len(uploads)),
+	}
+
+	var g errgroup.Group
+	g.SetLimit(s.batchConcurrency)
+	for i, upload := range uploads {
+		group.Children[i].Filename = upload.Filename
+		g.Go(func() error {
+			sub, err := s.Submit(ctx, upload.File, upload.Filename, opts)
+			if err != nil {
+				log.Printf("batch %s: submit %q: %v", group.ID, upload.Filename, err)
+				group.Children[i].Error = errorMessage(err, "submission failed")
+				return nil
+			}
+			group.Children[i].SubID = sub.SubID
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	if !anySubmitted(group) {
+		return nil, apperrors.NewExternalError("nova", errors.New("no image in the batch could be submitted"))
+	}
+	if err := s.groups.SaveGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("save group: %w", err)
+	}
+	return group, nil
+}
+
+// GetBatch returns the status of every job in a group. A job whose status
+// cannot be read is reported on its own entry, like a failed submission,
+// rather than failing the whole group.
+func (s *Service) GetBatch(ctx context.Context, groupID string, fetch bool) (*model.GroupResult, error) {
+	group, found, err := s.groups.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("get group: %w", err)
+	}
+	if !found {
+		return nil, apperrors.NewNotFoundError("group")
+	}
+
+	result := &model.GroupResult{
+		Group:   group,
+		Results: make([]*model.SolveResult, len(group.Children)),
+		Errors:  make([]string, len(group.Children)),
+	}
+
+	var g errgroup.Group
+	g.SetLimit(s.batchConcurrency)
+	for i, child := range group.Children {
+		if child.SubID == 0 {
+			continue
+		}
+		g.Go(func() error {
+			status, err := s.GetStatus(ctx, child.SubID, fetch)
+			if err != nil {
+				log.Printf("batch %s: job %d: %v", group.ID, child.SubID, err)
+				result.Errors[i] = errorMessage(err, "status unavailable")
+				return nil
+			}
+			result.Results[i] = status
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func anySubmitted(group *model.JobGroup) bool {
+	for _, child := range group.Children {
+		if child.SubID != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// errorMessage returns the message of an API error, which is safe to show
+// to the client, or fallback for any other error.
+func errorMessage(err error, fallback string) string {
+	var apiErr *apperrors.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Message
+	}
+	return fallback
+}
@@ -0,0 +1,114 @@
+package solve
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	apperrors "server/internal/errors"
+	"server/internal/model"
+)
+
+func (s *Service) checkCallback(opts model.SubmitOptions) error {
+	if opts.CallbackURL != "" && s.notifier == nil {
+		return apperrors.NewValidationError("callbacks are not enabled on this server")
+	}
+	return nil
+}
+
+// startJob stores a new submission and, if it has a callback, starts
+// watching it for completion.
+func (s *Service) startJob(ctx context.Context, job *model.JobRecord, opts model.SubmitOptions) {
+	job.Hints = opts.Hints
+	if opts.CallbackURL != "" {
+		job.Callback = &model.CallbackState{URL: opts.CallbackURL}
+	}
+	s.saveJob(ctx, job)
+
+	if job.Callback != nil {
+		s.watchCallback(job.SubID, job.Callback.URL)
+	}
+}
+
+// ResumeCallbacks restarts the watch of every job whose webhook was still
+// pending, such as those interrupted by a restart.
+func (s *Service) ResumeCallbacks(ctx context.Context) error {
+	if s.notifier == nil {
+		return nil
+	}
+	jobs, err := s.jobs.PendingCallbacks(ctx)
+	if err != nil {
+		return fmt.Errorf("list pending callbacks: %w", err)
+	}
+	for _, job := range jobs {
+		s.watchCallback(job.SubID, job.Callback.URL)
+	}
+	if len(jobs) > 0 {
+		log.Printf("resumed %d pending webhooks", len(jobs))
+	}
+	return nil
+}
+
+// watchCallback waits in the background for subID to finish and then
+// delivers the final result. Deliveries that exhaust their retries, and
+// jobs that do not finish before the watch gives up, are dead-lettered on
+// the job record so they are not resumed again.
+func (s *Service) watchCallback(subID int, callbackURL string) {
+	ctx := s.watcher.ctx
+	updates, err := s.watcher.subscribe(ctx, subID)
+	if err != nil {
+		log.Printf("watch callback for %d: %v", subID, err)
+		return
+	}
+
+	s.callbacks.Add(1)
+	go func() {
+		defer s.callbacks.Done()
+
+		var final *model.SolveResult
+		for result := range updates {
+			if result.IsFinal() {
+				final = result
+			}
+		}
+		if final == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			err := fmt.Errorf("job did not finish within %s", s.watcher.maxDuration)
+			s.recordCallback(ctx, subID, 0, err)
+			return
+		}
+
+		attempts, err := s.notifier.Notify(ctx, callbackURL, final)
+		if err != nil && ctx.Err() != nil {
+			log.Printf("webhook for job %d interrupted by shutdown after %d attempts", subID, attempts)
+			return
+		}
+		s.recordCallback(context.WithoutCancel(ctx), subID, attempts, err)
+	}()
+}
+
+func (s *Service) recordCallback(ctx context.Context, subID, attempts int, deliveryErr error) {
+	job, err := s.loadJob(ctx, subID)
+	if err != nil {
+		log.Printf("record callback for %d: %v", subID, err)
+		return
+	}
+	if job.Callback == nil {
+		return
+	}
+
+	now := time.Now()
+	job.Callback.Attempts += attempts
+	if deliveryErr != nil {
+		job.Callback.LastError = deliveryErr.Error()
+		job.Callback.DeadLetteredAt = &now
+		log.Printf("webhook for job %d dead-lettered after %d attempts: %v", subID, job.Callback.Attempts, deliveryErr)
+	} else {
+		job.Callback.LastError = ""
+		job.Callback.DeliveredAt = &now
+	}
+	s.saveJob(ctx, job)
+}
@@ -19,8 +19,27 @@ func classifyByName(name string) model.ObjectType {
 	return model.ObjectTypeStar
 }
 
+// simbadOTypes maps SIMBAD's short object type codes for deep-sky objects,
+// which cone searches return far more of than name lookups, to their kind.
+var simbadOTypes = map[string]model.DeepSkyObjectType{
+	"g": model.DSOGalaxy, "gig": model.DSOGalaxy, "gic": model.DSOGalaxy, "big": model.DSOGalaxy,
+	"ig": model.DSOGalaxy, "pag": model.DSOGalaxy, "sbg": model.DSOGalaxy, "syg": model.DSOGalaxy,
+	"sy1": model.DSOGalaxy, "sy2": model.DSOGalaxy, "agn": model.DSOGalaxy, "lin": model.DSOGalaxy,
+	"bcg": model.DSOGalaxy, "lsb": model.DSOGalaxy, "emg": model.DSOGalaxy, "h2g": model.DSOGalaxy,
+	"rg": model.DSOGalaxy, "grg": model.DSOGalaxy, "clg": model.DSOGalaxy, "cgg": model.DSOGalaxy,
+	"opc": model.DSOOpenCluster, "cl*": model.DSOOpenCluster, "as*": model.DSOOpenCluster,
+	"glc": model.DSOGlobularCluster,
+	"hii": model.DSONebula, "pn": model.DSONebula, "rne": model.DSONebula, "dne": model.DSONebula,
+	"gne": model.DSONebula, "ism": model.DSONebula, "emo": model.DSONebula, "moc": model.DSONebula,
+	"cld": model.DSONebula, "sfr": model.DSONebula, "bub": model.DSONebula,
+	"snr": model.DSOSupernova, "sn*": model.DSOSupernova,
+}
+
 func classifyByType(t string) model.ObjectType {
 	lower := strings.ToLower(t)
+	if _, ok := simbadOTypes[lower]; ok {
+		return model.ObjectTypeDSO
+	}
 	for _, k := range []string{"galaxy", "nebula", "cluster", "hii", "supernova", "cl*", "g ", "gxy", "snr"} {
 		if strings.Contains(lower, k) {
 			return model.ObjectTypeDSO
@@ -31,6 +50,9 @@ func classifyByType(t string) model.ObjectType {
 
 func classifyDSOType(t string) model.DeepSkyObjectType {
 	lower := strings.ToLower(t)
+	if dso, ok := simbadOTypes[lower]; ok {
+		return dso
+	}
 	switch {
 	case strings.Contains(lower, "galaxy") || strings.Contains(lower, "gxy") || lower == "g":
 		return model.DSOGalaxy
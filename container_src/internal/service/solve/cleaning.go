@@ -3,6 +3,7 @@ package solve
 import (
 	"strings"
 
+	"server/internal/client/catalog"
 	"server/internal/client/nova"
 )
 
@@ -82,3 +83,28 @@ func greekToSimbadName(name string) string {
 	}
 	return ""
 }
+
+// nameCandidates lists the spellings the catalogs are asked for, in order of
+// preference: the name itself, without its parenthetical, and with a
+// Greek letter spelled SIMBAD's way.
+func nameCandidates(name string) []string {
+	candidates := []string{name}
+	if base := extractNameWithoutParen(name); base != name {
+		candidates = append(candidates, base)
+	}
+	if simbadName := greekToSimbadName(name); simbadName != "" {
+		candidates = append(candidates, simbadName)
+	}
+	return candidates
+}
+
+// pickObject returns the catalog record for the first of name's candidates
+// that was found, or nil.
+func pickObject(name string, found map[string]*catalog.Object) *catalog.Object {
+	for _, candidate := range nameCandidates(name) {
+		if info, ok := found[candidate]; ok {
+			return info
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package solve
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+
+	"server/internal/client/catalog"
+	"server/internal/model"
+	"server/internal/wcs"
+)
+
+// coneFetchLimit is how many of the brightest objects of the requested
+// types are fetched per quantised cone. Paging applies to that set, so
+// every page of a search is served from one cached query.
+const coneFetchLimit = 500
+
+// ConeSearch lists the objects around a sky position, brightest first.
+func (s *ObjectService) ConeSearch(ctx context.Context, q model.ConeQuery) (*model.ConeResult, error) {
+	infos, err := s.catalog.ConeSearch(ctx, quantiseCone(q))
+	if err != nil {
+		return nil, catalogError(err)
+	}
+
+	var matches []model.NearbyObject
+	for _, info := range infos {
+		if info.RA == nil || info.Dec == nil {
+			continue
+		}
+		separation := wcs.Separation(q.RA, q.Dec, *info.RA, *info.Dec)
+		if separation > q.Radius {
+			continue
+		}
+		if q.MaxMagnitude != nil && (info.VMagnitude == nil || *info.VMagnitude > *q.MaxMagnitude) {
+			continue
+		}
+
+		// SIMBAD pads main identifiers ("M  42") to align catalogue numbers.
+		obj := describeObject(strings.Join(strings.Fields(info.Identifier), " "), info)
+		// Brightness is what a list of nearby targets is ordered by, so it
+		// is kept even for stars too faint for the other details.
+		obj.VMagnitude = info.VMagnitude
+		if len(q.Types) > 0 && !slices.Contains(q.Types, string(obj.Type)) && !slices.Contains(q.Types, string(obj.DSOType)) {
+			continue
+		}
+		matches = append(matches, model.NearbyObject{IdentifiedObject: *obj, Separation: separation})
+	}
+
+	slices.SortStableFunc(matches, compareBrightness)
+
+	result := &model.ConeResult{Total: len(matches), Truncated: len(infos) >= coneFetchLimit}
+	if q.Offset < len(matches) {
+		result.Objects = matches[q.Offset:min(q.Offset+q.Limit, len(matches))]
+	}
+	return result, nil
+}
+
+// compareBrightness orders by V magnitude, objects without one last, then
+// by distance from the center.
+func compareBrightness(a, b model.NearbyObject) int {
+	switch {
+	case a.VMagnitude != nil && b.VMagnitude != nil:
+		if *a.VMagnitude != *b.VMagnitude {
+			if *a.VMagnitude < *b.VMagnitude {
+				return -1
+			}
+			return 1
+		}
+	case a.VMagnitude != nil:
+		return -1
+	case b.VMagnitude != nil:
+		return 1
+	}
+	switch {
+	case a.Separation < b.Separation:
+		return -1
+	case a.Separation > b.Separation:
+		return 1
+	}
+	return 0
+}
+
+// quantiseCone snaps the cone to a power-of-ten grid no coarser than a
+// tenth of its radius (and no finer than 0.0001°), and widens it by one
+// grid step so it still covers the requested cone. Nearby requests then
+// share a catalog query and its cache entry; results are trimmed back to
+// the exact cone afterwards.
+func quantiseCone(q model.ConeQuery) catalog.ConeQuery {
+	scale := min(math.Pow(10, -math.Floor(math.Log10(q.Radius/10))), 1e4)
+	sq := catalog.ConeQuery{
+		RA:     math.Mod(math.Round(q.RA*scale)/scale, 360),
+		Dec:    math.Round(q.Dec*scale) / scale,
+		Radius: (math.Ceil(q.Radius*scale) + 1) / scale,
+		Types:  coneTypeFilter(q.Types),
+		Limit:  coneFetchLimit,
+	}
+	if q.MaxMagnitude != nil {
+		m := math.Ceil(*q.MaxMagnitude*10) / 10
+		sq.MaxMagnitude = &m
+	}
+	return sq
+}
+
+// coneTypeFilter turns the requested types into SIMBAD object type codes,
+// so the catalog filters before picking the brightest objects rather than
+// after. Stars are whatever classification does not count as deep-sky, so
+// a filter that includes them lists the codes to leave out instead.
+func coneTypeFilter(types []string) *catalog.TypeFilter {
+	if len(types) == 0 {
+		return nil
+	}
+	stars := slices.Contains(types, string(model.ObjectTypeStar))
+	allDSO := slices.Contains(types, string(model.ObjectTypeDSO))
+	filter := &catalog.TypeFilter{Exclude: stars}
+	for code, kind := range simbadOTypes {
+		selected := allDSO || slices.Contains(types, string(kind))
+		if selected != stars {
+			filter.Codes = append(filter.Codes, code)
+		}
+	}
+	if stars && len(filter.Codes) == 0 {
+		return nil
+	}
+	slices.Sort(filter.Codes)
+	return filter
+}
@@ -0,0 +1,76 @@
+package solve
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"server/internal/model"
+)
+
+// contentHash fingerprints an upload together with its options, so the
+// same image submitted with different hints or callbacks is solved
+// separately. It returns a reader positioned at the start of the content.
+func contentHash(file io.Reader, opts model.SubmitOptions) (string, io.Reader, error) {
+	h := sha256.New()
+
+	body := file
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		if _, err := io.Copy(h, seeker); err != nil {
+			return "", nil, err
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return "", nil, err
+		}
+	} else {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return "", nil, err
+		}
+		h.Write(data)
+		body = bytes.NewReader(data)
+	}
+
+	optsJSON, err := json.Marshal(opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("marshal options: %w", err)
+	}
+	h.Write([]byte{0})
+	h.Write(optsJSON)
+
+	return hex.EncodeToString(h.Sum(nil)), body, nil
+}
+
+// findDuplicate returns the earlier submission of the same content, unless
+// that job failed or was cancelled, in which case the image is resubmitted.
+func (s *Service) findDuplicate(ctx context.Context, hash string) (model.Submission, bool) {
+	subID, found, err := s.uploads.FindUpload(ctx, hash)
+	if err != nil {
+		log.Printf("find upload %s: %v", hash, err)
+		return model.Submission{}, false
+	}
+	if !found {
+		return model.Submission{}, false
+	}
+
+	job, err := s.loadJob(ctx, subID)
+	if err != nil {
+		log.Printf("load duplicate job %d: %v", subID, err)
+		return model.Submission{}, false
+	}
+	if job.Status == model.StatusFailure || job.Status == model.StatusCancelled {
+		return model.Submission{}, false
+	}
+	return model.Submission{SubID: subID, Deduplicated: true, Status: job.Status}, true
+}
+
+func (s *Service) rememberUpload(ctx context.Context, hash string, subID int) {
+	if err := s.uploads.SaveUpload(ctx, hash, subID); err != nil {
+		log.Printf("save upload %s: %v", hash, err)
+	}
+}
@@ -0,0 +1,66 @@
+package solve
+
+import (
+	"server/internal/client/catalog"
+	"server/internal/model"
+)
+
+// describeObject classifies the object named name from its catalog record, or
+// from the name alone when info is nil or gives no type.
+func describeObject(name string, info *catalog.Object) *model.IdentifiedObject {
+	obj := &model.IdentifiedObject{Identifier: name, Name: name}
+	if info == nil {
+		obj.Type = classifyByName(name)
+		return obj
+	}
+
+	obj.Type = classifyByType(info.ObjectType)
+	if info.ObjectType == "" {
+		obj.Type = classifyByName(name)
+	}
+	obj.Aliases = info.Identifiers
+	obj.Sources = info.Sources
+	applyDetails(obj, info)
+
+	if info.RA != nil && info.Dec != nil {
+		obj.RA = info.RA
+		obj.Dec = info.Dec
+		obj.Constellation = model.GetConstellationByCoords(*info.RA, *info.Dec)
+	}
+	return obj
+}
+
+// applyDetails copies the catalog measurements onto obj, whose Type is
+// already set. Stars only get details when brighter than V 3.0, matching
+// what the clients display; deep-sky objects always do.
+func applyDetails(obj *model.IdentifiedObject, info *catalog.Object) {
+	if obj.Type == model.ObjectTypeStar {
+		if info.VMagnitude == nil || *info.VMagnitude >= 3.0 {
+			return
+		}
+		obj.VMagnitude = info.VMagnitude
+		obj.Magnitudes = info.Magnitudes
+		obj.ColorIndexBV = info.ColorIndex("B", "V")
+		obj.SpectralClass = parseSpectralClass(info.SpectralType)
+		obj.DistanceParsecs = info.DistanceParsecs()
+		obj.DistanceErrorParsecs = info.DistanceErrorParsecs()
+		if info.PMRA != nil && info.PMDec != nil {
+			obj.ProperMotion = &model.ProperMotion{RA: *info.PMRA, Dec: *info.PMDec}
+		}
+		obj.RadialVelocity = info.RadialVelocity
+		return
+	}
+
+	obj.DSOType = classifyDSOType(info.ObjectType)
+	obj.VMagnitude = info.VMagnitude
+	obj.Magnitudes = info.Magnitudes
+	obj.RadialVelocity = info.RadialVelocity
+	obj.Redshift = info.Redshift
+	if info.MajorAxis != nil || info.MinorAxis != nil {
+		obj.Size = &model.AngularSize{
+			MajorAxis:     info.MajorAxis,
+			MinorAxis:     info.MinorAxis,
+			PositionAngle: info.PositionAngle,
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package solve
+
+import (
+	"context"
+	"sync"
+)
+
+// enrichments tracks in-flight fetchFullData runs so that cancelling a job
+// can stop its SIMBAD fan-out.
+type enrichments struct {
+	mu     sync.Mutex
+	next   uint64
+	active map[int]map[uint64]context.CancelFunc
+}
+
+func newEnrichments() *enrichments {
+	return &enrichments{active: make(map[int]map[uint64]context.CancelFunc)}
+}
+
+// start derives a cancellable context for subID. The returned func must be
+// called once the enrichment finishes.
+func (e *enrichments) start(ctx context.Context, subID int) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	e.mu.Lock()
+	id := e.next
+	e.next++
+	if e.active[subID] == nil {
+		e.active[subID] = make(map[uint64]context.CancelFunc)
+	}
+	e.active[subID][id] = cancel
+	e.mu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		e.mu.Lock()
+		delete(e.active[subID], id)
+		if len(e.active[subID]) == 0 {
+			delete(e.active, subID)
+		}
+		e.mu.Unlock()
+	}
+}
+
+func (e *enrichments) cancel(subID int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, cancel := range e.active[subID] {
+		cancel()
+	}
+}
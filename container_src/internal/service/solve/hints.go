@@ -0,0 +1,31 @@
+package solve
+
+import (
+	"server/internal/client/nova"
+	"server/internal/model"
+)
+
+func toUploadOptions(h model.SolveHints) nova.UploadOptions {
+	opts := nova.UploadOptions{
+		ScaleUnits:       string(h.ScaleUnits),
+		ScaleLower:       h.ScaleLower,
+		ScaleUpper:       h.ScaleUpper,
+		ScaleEst:         h.ScaleEst,
+		ScaleErr:         h.ScaleErr,
+		CenterRA:         h.CenterRA,
+		CenterDec:        h.CenterDec,
+		Radius:           h.Radius,
+		DownsampleFactor: h.DownsampleFactor,
+		TweakOrder:       h.TweakOrder,
+		Parity:           h.Parity,
+		UseSextractor:    h.UseSextractor,
+		CrpixCenter:      h.CrpixCenter,
+	}
+	switch {
+	case h.ScaleLower != nil && h.ScaleUpper != nil:
+		opts.ScaleType = "ul"
+	case h.ScaleEst != nil && h.ScaleErr != nil:
+		opts.ScaleType = "ev"
+	}
+	return opts
+}
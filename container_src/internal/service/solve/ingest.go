@@ -0,0 +1,42 @@
+package solve
+
+import (
+	"errors"
+	"io"
+	"log"
+
+	apperrors "server/internal/errors"
+	"server/internal/ingest"
+	"server/internal/model"
+)
+
+// ingestImage checks the upload's real format and prepares it for Nova.
+// Hints derived from the image's metadata fill in any the caller left
+// unset; the applied ones are returned alongside the image.
+func (s *Service) ingestImage(file io.Reader, opts *model.SubmitOptions) (*ingest.Image, *model.SolveHints, error) {
+	img, err := s.ingest.Process(file)
+	if err != nil {
+		var invalid *ingest.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, nil, apperrors.NewValidationError(invalid.Reason)
+		}
+		return nil, nil, err
+	}
+
+	derived := deriveHints(img)
+	if err := derived.Validate(); err != nil {
+		log.Printf("discard derived hints for %s image: %v", img.Format, err)
+		return img, nil, nil
+	}
+	return img, applyDerivedHints(&opts.Hints, derived), nil
+}
+
+func imageInfo(img *ingest.Image) *model.ImageInfo {
+	return &model.ImageInfo{
+		Format:         string(img.Format),
+		Width:          img.Width,
+		Height:         img.Height,
+		OriginalWidth:  img.OriginalWidth,
+		OriginalHeight: img.OriginalHeight,
+	}
+}
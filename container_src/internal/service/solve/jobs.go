@@ -0,0 +1,93 @@
+package solve
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	apperrors "server/internal/errors"
+	"server/internal/model"
+)
+
+// loadJob returns the stored record for subID. Records are only created
+// on submission, so an unknown ID is not found rather than adopted;
+// reading a job never writes one.
+func (s *Service) loadJob(ctx context.Context, subID int) (*model.JobRecord, error) {
+	job, found, err := s.jobs.Get(ctx, subID)
+	if err != nil {
+		return nil, fmt.Errorf("get job: %w", err)
+	}
+	if !found {
+		return nil, apperrors.NewNotFoundError("job")
+	}
+	return job, nil
+}
+
+func (s *Service) saveJob(ctx context.Context, job *model.JobRecord) {
+	if err := s.jobs.Save(ctx, job); err != nil {
+		log.Printf("save job %d: %v", job.SubID, err)
+	}
+}
+
+// recordResult re-reads the job and applies the observed status unless
+// the job has already finished. The store has no compare-and-swap, so a
+// cancellation saved between the read and the write can still be lost.
+func (s *Service) recordResult(ctx context.Context, subID int, result *model.SolveResult) *model.JobRecord {
+	job, err := s.loadJob(ctx, subID)
+	if err != nil {
+		log.Printf("record result for %d: %v", subID, err)
+		return model.NewJobRecord(subID, time.Now())
+	}
+	if job.Status.IsTerminal() {
+		return job
+	}
+
+	changed := job.Transition(result.Status, time.Now())
+	if result.NovaJobID != 0 && job.NovaJobID != result.NovaJobID {
+		job.NovaJobID = result.NovaJobID
+		changed = true
+	}
+	if result.Status == model.StatusSuccess {
+		stored := *result
+		stored.History = nil
+		stored.Callback = nil
+		job.Result = &stored
+	}
+	if changed {
+		s.saveJob(ctx, job)
+	}
+	return job
+}
+
+func (s *Service) isCancelled(ctx context.Context, subID int) bool {
+	job, found, err := s.jobs.Get(ctx, subID)
+	if err != nil {
+		log.Printf("get job %d: %v", subID, err)
+		return false
+	}
+	return found && job.IsCancelled()
+}
+
+func storedResult(job *model.JobRecord) *model.SolveResult {
+	if job.Result != nil {
+		result := *job.Result
+		return &result
+	}
+	return &model.SolveResult{
+		JobID:     fmt.Sprintf("%d", job.SubID),
+		Status:    job.Status,
+		NovaJobID: job.NovaJobID,
+	}
+}
+
+func withTimeline(result *model.SolveResult, job *model.JobRecord) *model.SolveResult {
+	result.CreatedAt = job.CreatedAt
+	result.UpdatedAt = job.UpdatedAt
+	result.CompletedAt = job.CompletedAt
+	result.History = job.History
+	result.Callback = job.Callback
+	result.Image = job.Image
+	result.AutoHints = job.AutoHints
+	return result
+}
@@ -0,0 +1,55 @@
+package solve
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"server/internal/client"
+	apperrors "server/internal/errors"
+	"server/internal/model"
+	"server/internal/service"
+	"server/internal/util/breaker"
+)
+
+var _ service.ObjectService = (*ObjectService)(nil)
+
+// ObjectService identifies objects by name through the same cleaning,
+// alternative spellings and classification a solve applies to Nova's
+// object names.
+type ObjectService struct {
+	catalog client.CatalogResolver
+}
+
+func NewObjectService(catalogResolver client.CatalogResolver) *ObjectService {
+	return &ObjectService{catalog: catalogResolver}
+}
+
+// Lookup returns the object the catalogs know by name, or a not-found error.
+func (s *ObjectService) Lookup(ctx context.Context, name string) (*model.IdentifiedObject, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, apperrors.NewValidationError("identifier is required")
+	}
+	if shouldSkipObject(name) {
+		return nil, apperrors.NewNotFoundError("object")
+	}
+
+	cleanedName := cleanObjectName(name)
+	found, err := s.catalog.QueryObjects(ctx, nameCandidates(cleanedName))
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	info := pickObject(cleanedName, found)
+	if info == nil {
+		return nil, apperrors.NewNotFoundError("object")
+	}
+	return describeObject(cleanedName, info), nil
+}
+
+func catalogError(err error) error {
+	if errors.Is(err, breaker.ErrOpen) {
+		return apperrors.NewUnavailableError(err.Error())
+	}
+	return apperrors.NewExternalError("catalog", err)
+}
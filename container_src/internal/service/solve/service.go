@@ -2,59 +2,250 @@ package solve
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"sync"
+	"time"
 
-	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 
 	"server/internal/client"
+	"server/internal/client/catalog"
 	"server/internal/client/nova"
+	"server/internal/config"
 	apperrors "server/internal/errors"
+	"server/internal/ingest"
 	"server/internal/model"
+	"server/internal/repository"
 	"server/internal/service"
+	"server/internal/wcs"
 )
 
 var _ service.SolveService = (*Service)(nil)
 
+// sharedUploadTimeout bounds an upload to Nova, which runs detached from
+// the request that started it.
+const sharedUploadTimeout = 5 * time.Minute
+
 type Service struct {
-	nova   client.NovaClient
-	simbad client.SimbadClient
-	apiKey string
+	nova        client.NovaClient
+	sessions    client.NovaSessions
+	catalog     client.CatalogResolver
+	jobs        repository.JobRepository
+	groups      repository.GroupRepository
+	uploads     repository.UploadRepository
+	notifier    service.CompletionNotifier
+	ingest      *ingest.Processor
+	enrichments *enrichments
+	solutions   *solutions
+	watcher     *watcher
+	callbacks   sync.WaitGroup
+
+	inflightUploads singleflight.Group
+
+	batchConcurrency int
+}
+
+// NewService wires the solve pipeline. notifier may be nil, in which case
+// submissions with a callback URL are rejected.
+func NewService(novaClient client.NovaClient, sessions client.NovaSessions, catalogResolver client.CatalogResolver, jobs repository.JobRepository, groups repository.GroupRepository, uploads repository.UploadRepository, notifier service.CompletionNotifier, cfg config.SolveConfig) *Service {
+	s := &Service{
+		nova:        novaClient,
+		sessions:    sessions,
+		catalog:     catalogResolver,
+		jobs:        jobs,
+		groups:      groups,
+		uploads:     uploads,
+		notifier:    notifier,
+		ingest:      ingest.NewProcessor(cfg.MaxImageEdge, cfg.MaxImagePixels),
+		enrichments: newEnrichments(),
+		solutions:   newSolutions(),
+
+		batchConcurrency: max(cfg.BatchConcurrency, 1),
+	}
+	s.watcher = newWatcher(s, cfg.PollMinInterval, cfg.PollMaxInterval, cfg.WatchMaxDuration)
+	return s
 }
 
-func NewService(novaClient client.NovaClient, simbadClient client.SimbadClient, apiKey string) *Service {
-	return &Service{nova: novaClient, simbad: simbadClient, apiKey: apiKey}
+// Close stops all background polling and pending callbacks. Open
+// subscriptions are closed.
+func (s *Service) Close() {
+	s.watcher.close()
+	s.callbacks.Wait()
 }
 
-func (s *Service) Submit(ctx context.Context, file io.Reader, filename string) (int, error) {
-	if s.apiKey == "" {
-		return 0, apperrors.NewValidationError("NOVA_API_KEY not set")
+// Submit validates and normalises an image, then uploads it to Nova. An
+// image identical to an earlier, still-viable submission with the same
+// options reuses that job instead.
+func (s *Service) Submit(ctx context.Context, file io.Reader, filename string, opts model.SubmitOptions) (model.Submission, error) {
+	if err := s.checkCallback(opts); err != nil {
+		return model.Submission{}, err
+	}
+	img, autoHints, err := s.ingestImage(file, &opts)
+	if err != nil {
+		return model.Submission{}, err
+	}
+	defer func() {
+		if err := img.Close(); err != nil {
+			log.Printf("remove upload %q: %v", filename, err)
+		}
+	}()
+	hash, body, err := contentHash(img.Body, opts)
+	if err != nil {
+		return model.Submission{}, fmt.Errorf("hash upload: %w", err)
 	}
-	session, err := s.nova.Login(ctx, s.apiKey)
+	if sub, ok := s.findDuplicate(ctx, hash); ok {
+		sub.AutoHints = autoHints
+		return sub, nil
+	}
+
+	v, err, shared := s.inflightUploads.Do(hash, func() (any, error) {
+		// Callers deduplicated onto this upload wait for it too, so it must
+		// not fail just because the first caller went away, and neither
+		// may recording it for later duplicates.
+		uploadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedUploadTimeout)
+		defer cancel()
+		subID, err := s.upload(uploadCtx, body, filename, imageInfo(img), autoHints, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.rememberUpload(uploadCtx, hash, subID)
+		return subID, nil
+	})
 	if err != nil {
-		return 0, apperrors.NewExternalError("nova", err)
+		return model.Submission{}, err
 	}
-	subID, err := s.nova.Upload(ctx, session, file, filename)
+	subID := v.(int)
+	if shared {
+		return model.Submission{SubID: subID, Deduplicated: true, Status: model.StatusQueued, AutoHints: autoHints}, nil
+	}
+
+	return model.Submission{SubID: subID, Status: model.StatusQueued, AutoHints: autoHints}, nil
+}
+
+func (s *Service) upload(ctx context.Context, file io.Reader, filename string, info *model.ImageInfo, autoHints *model.SolveHints, opts model.SubmitOptions) (int, error) {
+	submittedAt := time.Now()
+	attempt := 0
+	var subID int
+	err := s.withSession(ctx, func(session string) error {
+		if attempt++; attempt > 1 {
+			if err := rewind(file); err != nil {
+				return err
+			}
+		}
+		var err error
+		subID, err = s.nova.Upload(ctx, session, file, filename, toUploadOptions(opts.Hints))
+		return err
+	})
 	if err != nil {
-		return 0, apperrors.NewExternalError("nova", err)
+		return 0, novaError(err)
 	}
+
+	job := model.NewJobRecord(subID, submittedAt)
+	job.Filename = filename
+	job.Image = info
+	job.AutoHints = autoHints
+	s.startJob(ctx, job, opts)
 	return subID, nil
 }
 
+func (s *Service) SubmitURL(ctx context.Context, imageURL string, opts model.SubmitOptions) (model.Submission, error) {
+	if err := s.checkCallback(opts); err != nil {
+		return model.Submission{}, err
+	}
+	submittedAt := time.Now()
+	var subID int
+	err := s.withSession(ctx, func(session string) error {
+		var err error
+		subID, err = s.nova.URLUpload(ctx, session, imageURL, toUploadOptions(opts.Hints))
+		return err
+	})
+	if err != nil {
+		return model.Submission{}, novaError(err)
+	}
+
+	job := model.NewJobRecord(subID, submittedAt)
+	job.SourceURL = imageURL
+	s.startJob(ctx, job, opts)
+	return model.Submission{SubID: subID, Status: model.StatusQueued}, nil
+}
+
+func (s *Service) Cancel(ctx context.Context, subID int) error {
+	job, err := s.loadJob(ctx, subID)
+	if err != nil {
+		return err
+	}
+	if job.IsCancelled() {
+		s.enrichments.cancel(subID)
+		return nil
+	}
+	if job.Status.IsTerminal() {
+		return apperrors.NewConflictError(fmt.Sprintf("job already finished with status %s", job.Status))
+	}
+
+	job.Transition(model.StatusCancelled, time.Now())
+	if err := s.jobs.Save(ctx, job); err != nil {
+		return fmt.Errorf("save job: %w", err)
+	}
+
+	s.enrichments.cancel(subID)
+	return nil
+}
+
+// Subscribe streams status changes for subID until the job finishes, ctx
+// is done or the watch time limit passes. Subscribers to the same job
+// share one polling loop. Unknown jobs are not watched.
+func (s *Service) Subscribe(ctx context.Context, subID int) (<-chan *model.SolveResult, error) {
+	if _, err := s.loadJob(ctx, subID); err != nil {
+		return nil, err
+	}
+	updates, err := s.watcher.subscribe(ctx, subID)
+	if errors.Is(err, errWatcherClosed) {
+		return nil, apperrors.NewUnavailableError("server shutting down")
+	}
+	return updates, err
+}
+
 func (s *Service) GetStatus(ctx context.Context, subID int, fetch bool) (*model.SolveResult, error) {
+	job, err := s.loadJob(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status.IsTerminal() && (job.Status != model.StatusSuccess || job.Result != nil) {
+		return withTimeline(storedResult(job), job), nil
+	}
+
+	result, settled, err := s.pollNova(ctx, subID, fetch)
+	if err != nil {
+		return nil, err
+	}
+	if !settled {
+		return withTimeline(result, job), nil
+	}
+
+	job = s.recordResult(context.WithoutCancel(ctx), subID, result)
+	if job.IsCancelled() {
+		return withTimeline(storedResult(job), job), nil
+	}
+	return withTimeline(result, job), nil
+}
+
+// pollNova derives the current status from Nova. settled is false when the
+// result reflects a transient error rather than the job's actual state.
+func (s *Service) pollNova(ctx context.Context, subID int, fetch bool) (*model.SolveResult, bool, error) {
 	result := &model.SolveResult{JobID: fmt.Sprintf("%d", subID)}
 
 	sub, err := s.nova.GetSubmission(ctx, subID)
 	if err != nil {
 		result.Status = model.StatusFailure
-		return result, nil
+		return result, false, nil
 	}
 
 	if len(sub.Jobs) == 0 {
 		result.Status = model.StatusQueued
-		return result, nil
+		return result, true, nil
 	}
 
 	jobID := sub.Jobs[0]
@@ -63,24 +254,37 @@ func (s *Service) GetStatus(ctx context.Context, subID int, fetch bool) (*model.
 	status, err := s.nova.GetJobStatus(ctx, jobID)
 	if err != nil {
 		result.Status = model.StatusFailure
-		return result, nil
+		return result, false, nil
 	}
 
 	switch status {
 	case "failure":
 		result.Status = model.StatusFailure
-		return result, nil
+		return result, true, nil
 	case "success":
 		if !fetch {
 			result.Status = model.StatusGettingMoreDetails
-			return result, nil
+			return result, true, nil
 		}
 	default:
 		result.Status = model.StatusIdentifyingObjects
-		return result, nil
+		return result, true, nil
 	}
 
-	return s.fetchFullData(ctx, subID, jobID)
+	ctx, done := s.enrichments.start(ctx, subID)
+	defer done()
+
+	full, err := s.fetchFullData(ctx, subID, jobID)
+	if err != nil {
+		if s.isCancelled(context.WithoutCancel(ctx), subID) {
+			result.Status = model.StatusCancelled
+			return result, true, nil
+		}
+		log.Printf("fetch full data for %d: %v", subID, err)
+		result.Status = model.StatusFailure
+		return result, false, nil
+	}
+	return full, true, nil
 }
 
 func (s *Service) fetchFullData(ctx context.Context, subID, jobID int) (*model.SolveResult, error) {
@@ -90,19 +294,29 @@ func (s *Service) fetchFullData(ctx context.Context, subID, jobID int) (*model.S
 	}
 
 	info, err := s.nova.GetJobInfo(ctx, jobID)
-	if err != nil || len(info.ObjectsInField) == 0 {
+	if err != nil {
+		return nil, fmt.Errorf("job info: %w", err)
+	}
+	if len(info.ObjectsInField) == 0 {
 		result.Status = model.StatusFailure
 		return result, nil
 	}
+	// Nova measured the downsampled frame; results describe the original.
+	sx, sy, err := s.imageScale(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	result.Calibration = toCalibration(info.Calibration)
+	result.Calibration.PixScale /= (sx + sy) / 2
 
 	annotations, err := s.nova.GetAnnotations(ctx, jobID)
 	if err != nil {
-		result.Status = model.StatusFailure
-		return result, nil
+		return nil, fmt.Errorf("annotations: %w", err)
 	}
 
 	annMap := make(map[string]nova.Annotation)
 	for _, a := range annotations {
+		a.PixelX, a.PixelY = scalePixel(a.PixelX, sx), scalePixel(a.PixelY, sy)
 		for _, name := range a.Names {
 			for _, part := range splitAnnotationNames(name) {
 				annMap[part] = a
@@ -110,80 +324,93 @@ func (s *Service) fetchFullData(ctx context.Context, subID, jobID int) (*model.S
 		}
 	}
 
-	g, ctx := errgroup.WithContext(ctx)
-	g.SetLimit(10)
-	var mu sync.Mutex
-
-	for _, name := range info.ObjectsInField {
-		name := name
-		g.Go(func() error {
-			obj, err := s.processObject(ctx, name, annMap)
-			if err != nil {
-				log.Printf("process %s: %v", name, err)
-				return nil
-			}
-			if obj != nil {
-				mu.Lock()
-				result.Objects = append(result.Objects, *obj)
-				mu.Unlock()
-			}
-			return nil
-		})
+	solution, err := s.solution(ctx, subID, jobID)
+	if err != nil {
+		log.Printf("wcs for job %d: %v", jobID, err)
 	}
 
-	if err := g.Wait(); err != nil {
+	found := s.lookupObjects(ctx, info.ObjectsInField)
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
+	for _, name := range info.ObjectsInField {
+		if obj := s.processObject(name, annMap, solution, found); obj != nil {
+			result.Objects = append(result.Objects, *obj)
+		}
+	}
+
 	result.Status = model.StatusSuccess
 	result.AnnotatedImageURL = s.nova.AnnotatedImageURL(jobID)
 	return result, nil
 }
 
-func (s *Service) processObject(ctx context.Context, name string, annMap map[string]nova.Annotation) (*model.IdentifiedObject, error) {
+// processObject identifies one object from the catalog records in found.
+// Its pixel position comes from the matching Nova annotation, or is
+// projected through solution from the catalog coordinates when no
+// annotation matches.
+func (s *Service) processObject(name string, annMap map[string]nova.Annotation, solution *wcs.WCS, found map[string]*catalog.Object) *model.IdentifiedObject {
 	if shouldSkipObject(name) {
-		return nil, nil
+		return nil
 	}
 
 	cleanedName := cleanObjectName(name)
-	obj := &model.IdentifiedObject{Identifier: cleanedName, Name: cleanedName}
+	info := pickObject(cleanedName, found)
+	obj := describeObject(cleanedName, info)
 
 	if ann, ok := lookupAnnotation(cleanedName, annMap); ok {
 		obj.XCoordinate = ann.PixelX
 		obj.YCoordinate = ann.PixelY
+		obj.PositionSource = model.PositionAnnotation
+	} else if info != nil && info.RA != nil && info.Dec != nil && solution != nil {
+		if x, y, ok := solution.SkyToImage(*info.RA, *info.Dec); ok {
+			obj.XCoordinate = x
+			obj.YCoordinate = y
+			obj.PositionSource = model.PositionProjected
+		}
 	}
 
-	info, err := s.simbad.QueryObject(ctx, cleanedName)
-	if err != nil {
-		if base := extractNameWithoutParen(cleanedName); base != cleanedName {
-			info, err = s.simbad.QueryObject(ctx, base)
+	return obj
+}
+
+// lookupObjects resolves every spelling of every name in one batched catalog
+// query. On failure it returns nothing, leaving objects to be classified
+// by name.
+func (s *Service) lookupObjects(ctx context.Context, names []string) map[string]*catalog.Object {
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if shouldSkipObject(name) {
+			continue
 		}
-	}
-	if err != nil {
-		if simbadName := greekToSimbadName(cleanedName); simbadName != "" {
-			info, err = s.simbad.QueryObject(ctx, simbadName)
+		for _, candidate := range nameCandidates(cleanObjectName(name)) {
+			if !seen[candidate] {
+				seen[candidate] = true
+				candidates = append(candidates, candidate)
+			}
 		}
 	}
-	if err != nil {
-		obj.Type = classifyByName(cleanedName)
-		return obj, nil
+	if len(candidates) == 0 {
+		return nil
 	}
 
-	obj.Type = classifyByType(info.ObjectType)
-
-	if obj.Type == model.ObjectTypeStar {
-		if info.VMagnitude != nil && *info.VMagnitude < 3.0 {
-			obj.VMagnitude = info.VMagnitude
-			obj.SpectralClass = parseSpectralClass(info.SpectralType)
-			obj.DistanceParsecs = info.DistanceParsecs()
-		}
-	} else {
-		obj.DSOType = classifyDSOType(info.ObjectType)
+	found, err := s.catalog.QueryObjects(ctx, candidates)
+	if err != nil {
+		log.Printf("catalog lookup of %d names: %v", len(candidates), err)
+		return nil
 	}
+	return found
+}
 
-	if info.RA != nil && info.Dec != nil {
-		obj.Constellation = model.GetConstellationByCoords(*info.RA, *info.Dec)
+func toCalibration(c nova.Calibration) *model.Calibration {
+	return &model.Calibration{
+		RA:           c.RA,
+		Dec:          c.Dec,
+		Radius:       c.Radius,
+		PixScale:     c.PixScale,
+		Orientation:  c.Orientation,
+		Parity:       c.Parity,
+		WidthArcsec:  c.WidthArcsec,
+		HeightArcsec: c.HeightArcsec,
 	}
-
-	return obj, nil
 }
@@ -0,0 +1,264 @@
+package solve_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"testing"
+	"time"
+
+	"server/internal/client/catalog"
+	"server/internal/client/nova"
+	"server/internal/client/nova/novatest"
+	"server/internal/config"
+	"server/internal/model"
+	"server/internal/repository"
+	"server/internal/repository/memory"
+	"server/internal/service"
+	"server/internal/service/solve"
+)
+
+func newService(t *testing.T, srv *novatest.Server) *solve.Service {
+	t.Helper()
+	return newServiceWithJobs(t, srv, memory.NewJobRepository())
+}
+
+func newServiceWithJobs(t *testing.T, srv *novatest.Server, jobs repository.JobRepository) *solve.Service {
+	t.Helper()
+	return newServiceWithRepositories(t, srv, jobs, memory.NewUploadRepository())
+}
+
+func newServiceWithRepositories(t *testing.T, srv *novatest.Server, jobs repository.JobRepository, uploads repository.UploadRepository) *solve.Service {
+	t.Helper()
+	client := nova.NewClient(config.NovaConfig{
+		BaseURL: srv.URL,
+		Timeout: 5 * time.Second,
+		Retry:   config.RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	})
+	svc := solve.NewService(
+		client,
+		nova.NewSessionManager(client, "test-key", time.Hour),
+		catalog.NewChain(),
+		jobs,
+		memory.NewGroupRepository(),
+		uploads,
+		nil,
+		config.SolveConfig{PollMinInterval: time.Millisecond, PollMaxInterval: time.Millisecond, BatchConcurrency: 1},
+	)
+	t.Cleanup(svc.Close)
+	return svc
+}
+
+// testImage returns a blank PNG. Images of different sizes are distinct
+// uploads, which are not deduplicated.
+func testImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func submit(t *testing.T, svc *solve.Service, data []byte) int {
+	t.Helper()
+	sub, err := svc.Submit(context.Background(), bytes.NewReader(data), "field.png", model.SubmitOptions{})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	return sub.SubID
+}
+
+func TestServiceLifecycle(t *testing.T) {
+	srv := novatest.NewServer()
+	defer srv.Close()
+	srv.Enqueue(novatest.Lifecycle{QueuedPolls: 1, SolvingPolls: 2, Outcome: novatest.OutcomeSuccess, Result: novatest.DefaultLifecycle().Result})
+	svc := newService(t, srv)
+
+	data := testImage(t, 64, 48)
+	subID := submit(t, svc, data)
+	uploads := srv.Uploads()
+	if len(uploads) != 1 || uploads[0].SubID != subID || uploads[0].Filename != "field.png" {
+		t.Fatalf("uploads = %+v, want one for %d", uploads, subID)
+	}
+	if !bytes.Equal(uploads[0].Data, data) {
+		t.Error("uploaded image differs from the submitted one")
+	}
+
+	want := []model.JobStatus{
+		model.StatusQueued,
+		model.StatusIdentifyingObjects,
+		model.StatusIdentifyingObjects,
+		model.StatusSuccess,
+	}
+	var result *model.SolveResult
+	for i, status := range want {
+		var err error
+		result, err = svc.GetStatus(context.Background(), subID, true)
+		if err != nil {
+			t.Fatalf("poll %d: %v", i, err)
+		}
+		if result.Status != status {
+			t.Fatalf("poll %d: status = %s, want %s", i, result.Status, status)
+		}
+	}
+	if !result.IsFinal() {
+		t.Error("solved result is not final")
+	}
+	if c := result.Calibration; c == nil || c.RA != 83.5 || c.Dec != -0.5 {
+		t.Errorf("calibration = %+v, want the lifecycle's", c)
+	}
+	if len(result.Objects) == 0 {
+		t.Error("no objects identified")
+	}
+
+	// A finished job is served from the record without asking Nova again.
+	before := srv.Requests(novatest.EndpointJobs)
+	if _, err := svc.GetStatus(context.Background(), subID, true); err != nil {
+		t.Fatal(err)
+	}
+	if after := srv.Requests(novatest.EndpointJobs); after != before {
+		t.Errorf("finished job polled Nova %d more times", after-before)
+	}
+}
+
+func TestServiceUploadRetriesExpiredSession(t *testing.T) {
+	srv := novatest.NewServer()
+	defer srv.Close()
+	svc := newService(t, srv)
+
+	submit(t, svc, testImage(t, 64, 48))
+	srv.ExpireSessions()
+	data := testImage(t, 32, 24)
+	subID := submit(t, svc, data)
+
+	if n := srv.Requests(novatest.EndpointLogin); n != 2 {
+		t.Errorf("logins = %d, want 2", n)
+	}
+	if n := srv.Requests(novatest.EndpointUpload); n != 3 {
+		t.Errorf("upload requests = %d, want 3", n)
+	}
+	uploads := srv.Uploads()
+	if len(uploads) != 2 || uploads[1].SubID != subID {
+		t.Fatalf("uploads = %+v, want the retry accepted as %d", uploads, subID)
+	}
+	if !bytes.Equal(uploads[1].Data, data) {
+		t.Error("retried upload did not resend the whole image")
+	}
+}
+
+func TestServiceStatusRetriesUnavailable(t *testing.T) {
+	srv := novatest.NewServer()
+	defer srv.Close()
+	srv.Enqueue(novatest.Lifecycle{SolvingPolls: 1, Outcome: novatest.OutcomeSuccess, Result: novatest.DefaultLifecycle().Result})
+	svc := newService(t, srv)
+	subID := submit(t, svc, testImage(t, 64, 48))
+
+	srv.FailNext(novatest.EndpointSubmissions, novatest.Failure{Status: http.StatusServiceUnavailable})
+	srv.FailNext(novatest.EndpointJobs, novatest.Failure{Status: http.StatusServiceUnavailable})
+	result, err := svc.GetStatus(context.Background(), subID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != model.StatusIdentifyingObjects {
+		t.Errorf("status = %s, want %s", result.Status, model.StatusIdentifyingObjects)
+	}
+	if n := srv.Requests(novatest.EndpointSubmissions); n != 2 {
+		t.Errorf("submission requests = %d, want 2", n)
+	}
+	if n := srv.Requests(novatest.EndpointJobs); n != 2 {
+		t.Errorf("job requests = %d, want 2", n)
+	}
+}
+
+// unreadableJobs fails to read the record of one job, as when a store
+// times out or a record has expired before its group.
+type unreadableJobs struct {
+	*memory.JobRepository
+	subID int
+}
+
+func (r *unreadableJobs) Get(ctx context.Context, subID int) (*model.JobRecord, bool, error) {
+	if subID == r.subID {
+		return nil, false, errors.New("store unavailable")
+	}
+	return r.JobRepository.Get(ctx, subID)
+}
+
+func TestServiceBatchReportsUnreadableJob(t *testing.T) {
+	srv := novatest.NewServer()
+	defer srv.Close()
+	jobs := &unreadableJobs{JobRepository: memory.NewJobRepository()}
+	svc := newServiceWithJobs(t, srv, jobs)
+
+	uploads := []service.Upload{
+		{File: bytes.NewReader(testImage(t, 64, 48)), Filename: "a.png"},
+		{File: bytes.NewReader(testImage(t, 32, 24)), Filename: "b.png"},
+	}
+	group, err := svc.SubmitBatch(context.Background(), uploads, model.SubmitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs.subID = group.Children[0].SubID
+
+	result, err := svc.GetBatch(context.Background(), group.ID, true)
+	if err != nil {
+		t.Fatalf("GetBatch: %v", err)
+	}
+	if result.Errors[0] == "" || result.Results[0] != nil {
+		t.Errorf("first job: error %q, result %+v, want the read error", result.Errors[0], result.Results[0])
+	}
+	if result.Errors[1] != "" || result.Results[1] == nil {
+		t.Errorf("second job: error %q, want its status", result.Errors[1])
+	}
+}
+
+// contextUploads fails, as a remote store would, once the caller's context
+// is done.
+type contextUploads struct {
+	repository.UploadRepository
+}
+
+func (r contextUploads) FindUpload(ctx context.Context, hash string) (int, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+	return r.UploadRepository.FindUpload(ctx, hash)
+}
+
+func (r contextUploads) SaveUpload(ctx context.Context, hash string, subID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.UploadRepository.SaveUpload(ctx, hash, subID)
+}
+
+func TestServiceRemembersUploadAfterSubmitterLeaves(t *testing.T) {
+	srv := novatest.NewServer()
+	defer srv.Close()
+	svc := newServiceWithRepositories(t, srv, memory.NewJobRepository(), contextUploads{memory.NewUploadRepository()})
+	data := testImage(t, 64, 48)
+
+	// The upload runs on its own context, so it completes even though the
+	// submitter has already gone away, and so must recording it.
+	gone, cancel := context.WithCancel(context.Background())
+	cancel()
+	first, err := svc.Submit(gone, bytes.NewReader(data), "field.png", model.SubmitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := svc.Submit(context.Background(), bytes.NewReader(data), "field.png", model.SubmitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Deduplicated || second.SubID != first.SubID {
+		t.Errorf("resubmission = %+v, want a duplicate of %d", second, first.SubID)
+	}
+	if uploads := srv.Uploads(); len(uploads) != 1 {
+		t.Errorf("%d uploads to Nova, want 1", len(uploads))
+	}
+}
@@ -0,0 +1,53 @@
+package solve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"server/internal/client/nova"
+	apperrors "server/internal/errors"
+	"server/internal/util/breaker"
+)
+
+// withSession runs fn with the shared Nova session. If Nova rejects the
+// session, it is dropped and fn is retried once with a fresh one.
+func (s *Service) withSession(ctx context.Context, fn func(session string) error) error {
+	session, err := s.sessions.Session(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(session)
+	if !errors.Is(err, nova.ErrInvalidSession) {
+		return err
+	}
+
+	s.sessions.Invalidate(session)
+	session, err = s.sessions.Session(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(session)
+}
+
+// rewind resets an upload body before a retry. Bodies that cannot seek
+// cannot be resent.
+func rewind(file io.Reader) error {
+	seeker, ok := file.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("upload body cannot be replayed: %w", nova.ErrInvalidSession)
+	}
+	_, err := seeker.Seek(0, io.SeekStart)
+	return err
+}
+
+func novaError(err error) error {
+	if errors.Is(err, nova.ErrMissingAPIKey) {
+		return apperrors.NewValidationError("NOVA_API_KEY not set")
+	}
+	if errors.Is(err, breaker.ErrOpen) {
+		return apperrors.NewUnavailableError(err.Error())
+	}
+	return apperrors.NewExternalError("nova", err)
+}
@@ -0,0 +1,123 @@
+package solve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	apperrors "server/internal/errors"
+	"server/internal/model"
+	"server/internal/wcs"
+)
+
+const maxCachedSolutions = 256
+
+// solutions caches parsed WCS solutions by submission ID. A solved job's
+// WCS never changes, so entries never go stale.
+type solutions struct {
+	mu    sync.RWMutex
+	byJob map[int]*wcs.WCS
+}
+
+func newSolutions() *solutions {
+	return &solutions{byJob: make(map[int]*wcs.WCS)}
+}
+
+func (c *solutions) get(subID int) (*wcs.WCS, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	w, ok := c.byJob[subID]
+	return w, ok
+}
+
+func (c *solutions) put(subID int, w *wcs.WCS) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.byJob) >= maxCachedSolutions {
+		for k := range c.byJob {
+			delete(c.byJob, k)
+			break
+		}
+	}
+	c.byJob[subID] = w
+}
+
+// Solution returns the WCS solution of a solved submission.
+func (s *Service) Solution(ctx context.Context, subID int) (*wcs.WCS, error) {
+	if w, ok := s.solutions.get(subID); ok {
+		return w, nil
+	}
+
+	jobID, err := s.solvedJobID(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	return s.solution(ctx, subID, jobID)
+}
+
+func (s *Service) solution(ctx context.Context, subID, jobID int) (*wcs.WCS, error) {
+	if w, ok := s.solutions.get(subID); ok {
+		return w, nil
+	}
+	header, err := s.nova.GetWCSFile(ctx, jobID)
+	if err != nil {
+		return nil, apperrors.NewExternalError("nova", err)
+	}
+	w, err := wcs.Parse(header)
+	if err != nil {
+		return nil, apperrors.NewExternalError("nova", fmt.Errorf("parse wcs: %w", err))
+	}
+	sx, sy, err := s.imageScale(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	w = w.Rescale(sx, sy)
+	s.solutions.put(subID, w)
+	return w, nil
+}
+
+// imageScale returns how far the job's image was downsampled before
+// solving, so Nova's pixel coordinates can be mapped back to the upload.
+func (s *Service) imageScale(ctx context.Context, subID int) (sx, sy float64, err error) {
+	job, found, err := s.jobs.Get(ctx, subID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get job: %w", err)
+	}
+	if !found {
+		return 1, 1, nil
+	}
+	sx, sy = job.Image.Scale()
+	return sx, sy, nil
+}
+
+// scalePixel maps a zero-based coordinate of the solved image to the
+// original one, keeping pixel edges aligned.
+func scalePixel(p, scale float64) float64 {
+	return (p+0.5)*scale - 0.5
+}
+
+func (s *Service) solvedJobID(ctx context.Context, subID int) (int, error) {
+	job, err := s.loadJob(ctx, subID)
+	if err != nil {
+		return 0, err
+	}
+	if job.Status == model.StatusSuccess && job.NovaJobID != 0 {
+		return job.NovaJobID, nil
+	}
+	if job.Status.IsTerminal() {
+		return 0, apperrors.NewConflictError(fmt.Sprintf("job has no solution (status %s)", job.Status))
+	}
+
+	result, settled, err := s.pollNova(ctx, subID, false)
+	if err != nil {
+		return 0, err
+	}
+	if !settled {
+		return 0, apperrors.NewExternalError("nova", errors.New("could not get job status"))
+	}
+	if result.Status != model.StatusGettingMoreDetails && result.Status != model.StatusSuccess {
+		return 0, apperrors.NewConflictError(fmt.Sprintf("job is not solved yet (status %s)", result.Status))
+	}
+	return result.NovaJobID, nil
+}
@@ -0,0 +1,215 @@
+package solve
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	apperrors "server/internal/errors"
+	"server/internal/model"
+)
+
+var errWatcherClosed = errors.New("watcher closed")
+
+// watcher polls Nova on behalf of every subscriber to a job, so a job is
+// polled on one backoff schedule however many clients are listening.
+type watcher struct {
+	svc         *Service
+	minInterval time.Duration
+	maxInterval time.Duration
+	maxDuration time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	closed  bool
+	watches map[int]*watch
+}
+
+type watch struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	subs   map[chan *model.SolveResult]struct{}
+	last   *model.SolveResult
+}
+
+// newWatcher returns a watcher whose polling loops give up after
+// maxDuration, so a job that never finishes is not polled forever. A
+// maxDuration of zero disables the limit.
+func newWatcher(svc *Service, minInterval, maxInterval, maxDuration time.Duration) *watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watcher{
+		svc:         svc,
+		minInterval: minInterval,
+		maxInterval: maxInterval,
+		maxDuration: maxDuration,
+		ctx:         ctx,
+		cancel:      cancel,
+		watches:     make(map[int]*watch),
+	}
+}
+
+// subscribe returns a channel that receives every status change of subID.
+// The channel is closed after the final status, when ctx ends, or when
+// the watcher is closed.
+func (w *watcher) subscribe(ctx context.Context, subID int) (<-chan *model.SolveResult, error) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil, errWatcherClosed
+	}
+
+	wt, ok := w.watches[subID]
+	if !ok {
+		wctx, cancel := w.watchContext()
+		wt = &watch{
+			cancel: cancel,
+			done:   make(chan struct{}),
+			subs:   make(map[chan *model.SolveResult]struct{}),
+		}
+		w.watches[subID] = wt
+		w.wg.Add(1)
+		go w.run(wctx, subID, wt)
+	}
+
+	ch := make(chan *model.SolveResult, 8)
+	wt.subs[ch] = struct{}{}
+	if wt.last != nil {
+		ch <- wt.last
+	}
+	w.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.unsubscribe(subID, wt, ch)
+		case <-wt.done:
+		}
+	}()
+	return ch, nil
+}
+
+func (w *watcher) watchContext() (context.Context, context.CancelFunc) {
+	if w.maxDuration > 0 {
+		return context.WithTimeout(w.ctx, w.maxDuration)
+	}
+	return context.WithCancel(w.ctx)
+}
+
+func (w *watcher) unsubscribe(subID int, wt *watch, ch chan *model.SolveResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := wt.subs[ch]; !ok {
+		return
+	}
+	delete(wt.subs, ch)
+	close(ch)
+
+	if len(wt.subs) == 0 {
+		wt.cancel()
+		if w.watches[subID] == wt {
+			delete(w.watches, subID)
+		}
+	}
+}
+
+func (w *watcher) run(ctx context.Context, subID int, wt *watch) {
+	defer w.wg.Done()
+	defer w.finish(subID, wt)
+
+	interval := w.minInterval
+	var lastStatus model.JobStatus
+
+	for {
+		result, err := w.svc.GetStatus(ctx, subID, false)
+		if err == nil && result.Status == model.StatusGettingMoreDetails {
+			if result.Status != lastStatus {
+				w.publish(wt, result)
+				lastStatus = result.Status
+			}
+			result, err = w.svc.GetStatus(ctx, subID, true)
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				log.Printf("watch %d: gave up after %s", subID, w.maxDuration)
+			}
+			return
+		case errors.Is(err, apperrors.ErrNotFound):
+			return
+		case err != nil:
+			log.Printf("watch %d: %v", subID, err)
+			interval = w.backoff(interval)
+		case result.Status.IsTerminal() && !result.IsFinal():
+			// A transient Nova error, not the job's real outcome.
+			interval = w.backoff(interval)
+		case result.Status != lastStatus:
+			w.publish(wt, result)
+			lastStatus = result.Status
+			interval = w.minInterval
+		default:
+			interval = w.backoff(interval)
+		}
+
+		if result.IsFinal() {
+			return
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (w *watcher) backoff(interval time.Duration) time.Duration {
+	return min(interval*3/2, w.maxInterval)
+}
+
+func (w *watcher) publish(wt *watch, result *model.SolveResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wt.last = result
+	for ch := range wt.subs {
+		select {
+		case ch <- result:
+		default:
+			log.Printf("watch subscriber too slow, dropping %s update", result.Status)
+		}
+	}
+}
+
+func (w *watcher) finish(subID int, wt *watch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for ch := range wt.subs {
+		delete(wt.subs, ch)
+		close(ch)
+	}
+	if w.watches[subID] == wt {
+		delete(w.watches, subID)
+	}
+	wt.cancel()
+	close(wt.done)
+}
+
+// close stops every watch and waits for the polling loops to exit.
+func (w *watcher) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	w.cancel()
+	w.wg.Wait()
+}
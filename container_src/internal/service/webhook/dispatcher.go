@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"server/internal/config"
+	"server/internal/model"
+	"server/internal/service"
+	"server/internal/util/httputil"
+	"server/internal/view"
+)
+
+const (
+	SignatureHeader = "X-SkyMatch-Signature"
+	TimestampHeader = "X-SkyMatch-Timestamp"
+	EventHeader     = "X-SkyMatch-Event"
+
+	eventSolveCompleted = "solve.completed"
+)
+
+var _ service.CompletionNotifier = (*Dispatcher)(nil)
+
+// errPermanent marks a delivery failure that retrying will not fix.
+var errPermanent = errors.New("permanent delivery failure")
+
+// Dispatcher POSTs finished jobs to their callback URLs, signing each body
+// with HMAC-SHA256 and retrying failed deliveries with exponential backoff.
+type Dispatcher struct {
+	http        *http.Client
+	secret      []byte
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// NewDispatcher returns a Dispatcher whose client only connects to public
+// addresses and never uses a proxy, since callback URLs come from users.
+func NewDispatcher(cfg config.WebhookConfig) *Dispatcher {
+	dialer := &net.Dialer{
+		Timeout:   cfg.Timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   httputil.PublicDialControl,
+	}
+	return &Dispatcher{
+		http: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: cfg.Timeout,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		secret:      []byte(cfg.Secret),
+		maxAttempts: cfg.MaxAttempts,
+		baseDelay:   cfg.BaseDelay,
+		maxDelay:    cfg.MaxDelay,
+	}
+}
+
+// Notify delivers result to callbackURL. It returns the number of attempts
+// made and the last error if every attempt failed.
+func (d *Dispatcher) Notify(ctx context.Context, callbackURL string, result *model.SolveResult) (int, error) {
+	body, err := json.Marshal(view.NewSolveStatusResponse(result))
+	if err != nil {
+		return 0, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	delay := d.baseDelay
+	for attempt := 1; ; attempt++ {
+		err = d.deliver(ctx, callbackURL, body)
+		if err == nil {
+			return attempt, nil
+		}
+		if errors.Is(err, errPermanent) || attempt >= d.maxAttempts {
+			return attempt, err
+		}
+		log.Printf("webhook %s attempt %d: %v", callbackURL, attempt, err)
+
+		wait := delay/2 + rand.N(delay/2+1)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+		delay = min(delay*2, d.maxDelay)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, callbackURL string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w: %w", err, errPermanent)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventSolveCompleted)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(d.secret, timestamp, body))
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		if errors.Is(err, httputil.ErrNonPublicAddress) {
+			return fmt.Errorf("POST: %w: %w", err, errPermanent)
+		}
+		return fmt.Errorf("POST: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("failed to close response body: %v", err)
+		}
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("status %d", resp.StatusCode)
+	default:
+		return fmt.Errorf("status %d: %w", resp.StatusCode, errPermanent)
+	}
+}
+
+// Sign computes the hex HMAC-SHA256 of "timestamp.body". Receivers verify a
+// delivery by recomputing it from the timestamp header and the raw body.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
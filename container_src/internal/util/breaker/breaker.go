@@ -0,0 +1,195 @@
+// Package breaker implements a circuit breaker for calls to an upstream
+// service.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"server/internal/config"
+	"server/internal/util/httputil"
+)
+
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half-open"
+)
+
+// ErrOpen is matched by the errors returned while a breaker is open.
+var ErrOpen = errors.New("circuit breaker open")
+
+// OpenError is returned without calling the upstream while its breaker is
+// open or already probing.
+type OpenError struct {
+	Name    string
+	RetryAt time.Time
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s unavailable: circuit breaker open until %s", e.Name, e.RetryAt.Format(time.RFC3339))
+}
+
+func (e *OpenError) Unwrap() error {
+	return ErrOpen
+}
+
+// Breaker opens after FailureThreshold consecutive failures and rejects
+// calls for OpenTimeout. It then lets one call through at a time
+// (half-open) and closes again after SuccessThreshold successes; any
+// failure while half-open reopens it.
+type Breaker struct {
+	name             string
+	failureThreshold int
+	successThreshold int
+	openTimeout      time.Duration
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	successes int
+	probing   bool
+	openedAt  time.Time
+	lastError string
+}
+
+// Status is a snapshot of a breaker for reporting.
+type Status struct {
+	Name      string
+	State     State
+	Failures  int
+	OpenedAt  *time.Time
+	RetryAt   *time.Time
+	LastError string
+}
+
+func New(name string, cfg config.BreakerConfig) *Breaker {
+	return &Breaker{
+		name:             name,
+		failureThreshold: max(cfg.FailureThreshold, 1),
+		successThreshold: max(cfg.SuccessThreshold, 1),
+		openTimeout:      cfg.OpenTimeout,
+		state:            StateClosed,
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow reports whether a call may go ahead. Every allowed call must be
+// followed by Done with its outcome.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		retryAt := b.openedAt.Add(b.openTimeout)
+		if time.Now().Before(retryAt) {
+			return &OpenError{Name: b.name, RetryAt: retryAt}
+		}
+		b.state = StateHalfOpen
+		b.successes = 0
+		b.probing = false
+		fallthrough
+	case StateHalfOpen:
+		if b.probing {
+			return &OpenError{Name: b.name, RetryAt: time.Now().Add(b.openTimeout)}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Done records the outcome of an allowed call. Cancellations by the caller
+// and client errors such as 404 say nothing about the upstream's health
+// and leave the breaker as it is.
+func (b *Breaker) Done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.probing = false
+	}
+	switch {
+	case err == nil:
+		b.succeed()
+	case !isFailure(err):
+	default:
+		b.fail(err)
+	}
+}
+
+func (b *Breaker) succeed() {
+	switch b.state {
+	case StateHalfOpen:
+		if b.successes++; b.successes >= b.successThreshold {
+			b.state = StateClosed
+			b.failures = 0
+		}
+	case StateClosed:
+		b.failures = 0
+	}
+}
+
+func (b *Breaker) fail(err error) {
+	b.lastError = err.Error()
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.failureThreshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func isFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *httputil.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	return true
+}
+
+// Status reports the state Allow would act on: an open breaker whose
+// timeout has passed is reported as half-open, since the next call probes.
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Status{Name: b.name, State: b.state, Failures: b.failures, LastError: b.lastError}
+	if b.state == StateOpen && !time.Now().Before(b.openedAt.Add(b.openTimeout)) {
+		s.State = StateHalfOpen
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.openTimeout)
+		s.OpenedAt = &openedAt
+		s.RetryAt = &retryAt
+	}
+	return s
+}
+
+// Call runs fn through b. classify maps fn's error to the outcome
+// recorded, so callers can exclude expected errors such as "not found";
+// fn's own error is always returned.
+func Call[T any](b *Breaker, fn func() (T, error), classify func(error) error) (T, error) {
+	if err := b.Allow(); err != nil {
+		var zero T
+		return zero, err
+	}
+	v, err := fn()
+	if classify != nil {
+		b.Done(classify(err))
+	} else {
+		b.Done(err)
+	}
+	return v, err
+}
@@ -16,23 +16,38 @@ import (
 type Client struct {
 	http    *http.Client
 	baseURL string
+	timeout time.Duration
+	retry   RetryPolicy
+	// retryPosts allows POST requests to be retried, see Idempotent.
+	retryPosts bool
 }
 
-func NewClient(baseURL string, timeout time.Duration) *Client {
+// NewClient returns a client for baseURL. timeout bounds each attempt
+// rather than the whole request, so retries get a fresh budget within the
+// caller's deadline.
+func NewClient(baseURL string, timeout time.Duration, retry RetryPolicy) *Client {
 	transport := &http.Transport{
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 10,
 		IdleConnTimeout:     90 * time.Second,
 	}
 	return &Client{
-		http: &http.Client{
-			Timeout:   timeout,
-			Transport: transport,
-		},
+		http:    &http.Client{Transport: transport},
 		baseURL: baseURL,
+		timeout: timeout,
+		retry:   retry,
 	}
 }
 
+// Idempotent returns a client that retries POST requests like GETs. Use it
+// only for requests that are safe to repeat; uploads whose file cannot be
+// rewound are still sent once.
+func (c *Client) Idempotent() *Client {
+	clone := *c
+	clone.retryPosts = true
+	return &clone
+}
+
 func (c *Client) Get(ctx context.Context, path string, v any) error {
 	return c.GetWithParams(ctx, path, nil, v)
 }
@@ -43,57 +58,102 @@ func (c *Client) GetWithParams(ctx context.Context, path string, params url.Valu
 		reqURL += "?" + params.Encode()
 	}
 
+	return c.withRetry(ctx, true, func(ctx context.Context) error {
+		resp, err := c.get(ctx, reqURL, path)
+		if err != nil {
+			return err
+		}
+		defer closeBody(resp)
+		return json.NewDecoder(resp.Body).Decode(v)
+	})
+}
+
+// GetBytes fetches path and returns the raw response body, read up to limit bytes.
+func (c *Client) GetBytes(ctx context.Context, path string, limit int64) ([]byte, error) {
+	var data []byte
+	err := c.withRetry(ctx, true, func(ctx context.Context) error {
+		resp, err := c.get(ctx, c.baseURL+path, path)
+		if err != nil {
+			return err
+		}
+		defer closeBody(resp)
+		data, err = io.ReadAll(io.LimitReader(resp.Body, limit))
+		return err
+	})
+	return data, err
+}
+
+// get makes a single GET request. Responses with an error status are
+// closed and returned as a *StatusError.
+func (c *Client) get(ctx context.Context, reqURL, path string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return fmt.Errorf("GET %s: %w", path, err)
+		return nil, fmt.Errorf("GET %s: %w", path, err)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("failed to close response body: %v", err)
-		}
-	}()
-
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("GET %s: status %d", path, resp.StatusCode)
+		closeBody(resp)
+		return nil, newStatusError(resp, http.MethodGet, path)
 	}
-	return json.NewDecoder(resp.Body).Decode(v)
+	return resp, nil
 }
 
-func (c *Client) PostForm(ctx context.Context, path string, fields map[string]string, file io.Reader, filename string) (*http.Response, error) {
-	body := &bytes.Buffer{}
-	w := multipart.NewWriter(body)
+func closeBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if err := resp.Body.Close(); err != nil {
+		log.Printf("failed to close response body: %v", err)
+	}
+}
 
+// PostForm sends fields as the request-json form field and file as the
+// file part. The file is streamed into the request rather than buffered,
+// so it is read exactly once per call; if it can seek, its remaining size
+// sets the Content-Length.
+func (c *Client) PostForm(ctx context.Context, path string, fields any, file io.Reader, filename string) (*http.Response, error) {
 	jsonData, err := json.Marshal(fields)
 	if err != nil {
 		return nil, fmt.Errorf("marshal fields: %w", err)
 	}
+
+	// Only the multipart framing is rendered up front; the file is
+	// spliced in between the head and the closing boundary.
+	head := &bytes.Buffer{}
+	w := multipart.NewWriter(head)
 	if writeErr := w.WriteField("request-json", string(jsonData)); writeErr != nil {
 		return nil, fmt.Errorf("write field: %w", writeErr)
 	}
-
 	if file != nil {
-		part, createErr := w.CreateFormFile("file", filename)
-		if createErr != nil {
+		if _, createErr := w.CreateFormFile("file", filename); createErr != nil {
 			return nil, fmt.Errorf("create form file: %w", createErr)
 		}
-		if _, copyErr := io.Copy(part, file); copyErr != nil {
-			return nil, fmt.Errorf("copy file: %w", copyErr)
-		}
 	}
-
+	headLen := head.Len()
 	if closeErr := w.Close(); closeErr != nil {
 		return nil, fmt.Errorf("close writer: %w", closeErr)
 	}
+	tail := head.Bytes()[headLen:]
+	head.Truncate(headLen)
+
+	body := io.MultiReader(head, bytes.NewReader(tail))
+	contentLength := int64(head.Len() + len(tail))
+	if file != nil {
+		body = io.MultiReader(head, file, bytes.NewReader(tail))
+		if size, ok := remaining(file); ok {
+			contentLength += size
+		} else {
+			contentLength = -1
+		}
+	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, body)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
+	req.ContentLength = contentLength
 	req.Header.Set("Content-Type", w.FormDataContentType())
 
 	resp, err := c.http.Do(req)
@@ -103,19 +163,61 @@ func (c *Client) PostForm(ctx context.Context, path string, fields map[string]st
 	return resp, nil
 }
 
-func (c *Client) PostFormDecode(ctx context.Context, path string, fields map[string]string, file io.Reader, filename string, v any) error {
-	resp, err := c.PostForm(ctx, path, fields, file, filename)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("failed to close response body: %v", err)
+// remaining reports how many bytes are left to read from r, if it can
+// tell without consuming it.
+func remaining(r io.Reader) (int64, bool) {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len()), true
+	case io.Seeker:
+		cur, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		end, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, false
 		}
-	}()
+		if _, err := v.Seek(cur, io.SeekStart); err != nil {
+			return 0, false
+		}
+		return end - cur, true
+	}
+	return 0, false
+}
 
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("POST %s: status %d", path, resp.StatusCode)
+// PostFormDecode posts a form and decodes the JSON response. It is only
+// retried on a client from Idempotent, and only if file is nil or can be
+// rewound.
+func (c *Client) PostFormDecode(ctx context.Context, path string, fields any, file io.Reader, filename string, v any) error {
+	retry := c.retryPosts
+	var start int64
+	if file != nil {
+		seeker, ok := file.(io.Seeker)
+		if ok {
+			var err error
+			start, err = seeker.Seek(0, io.SeekCurrent)
+			ok = err == nil
+		}
+		retry = retry && ok
 	}
-	return json.NewDecoder(resp.Body).Decode(v)
+
+	attempt := 0
+	return c.withRetry(ctx, retry, func(ctx context.Context) error {
+		if attempt++; attempt > 1 && file != nil {
+			if _, err := file.(io.Seeker).Seek(start, io.SeekStart); err != nil {
+				return fmt.Errorf("rewind file: %w", err)
+			}
+		}
+		resp, err := c.PostForm(ctx, path, fields, file, filename)
+		if err != nil {
+			return err
+		}
+		defer closeBody(resp)
+
+		if resp.StatusCode >= 400 {
+			return newStatusError(resp, http.MethodPost, path)
+		}
+		return json.NewDecoder(resp.Body).Decode(v)
+	})
 }
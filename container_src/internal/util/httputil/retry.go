@@ -0,0 +1,138 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how Client retries failed requests. Refused or
+// dropped connections, timeouts, 429 and 5xx responses are retried with
+// jittered exponential backoff. The zero value makes a single attempt.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// StatusError is returned for responses with a status of 400 or above.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// RetryAfter is the delay requested by a Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: status %d", e.Method, e.Path, e.StatusCode)
+}
+
+// Temporary reports whether the status is worth retrying.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func newStatusError(resp *http.Response, method, path string) *StatusError {
+	return &StatusError{
+		Method:     method,
+		Path:       path,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter reads a Retry-After value given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// withRetry runs attempt until it succeeds, fails with a permanent error
+// or runs out of attempts. Each attempt gets the client's timeout, bounded
+// by ctx. No retry is started that could not finish before ctx's deadline,
+// and a Retry-After longer than the maximum delay ends the retries.
+func (c *Client) withRetry(ctx context.Context, retry bool, attempt func(ctx context.Context) error) error {
+	attempts := 1
+	if retry {
+		attempts = max(c.retry.MaxAttempts, 1)
+	}
+
+	delay := c.retry.BaseDelay
+	for n := 1; ; n++ {
+		err := c.attempt(ctx, attempt)
+		if err == nil || n >= attempts || !retryable(ctx, err) {
+			return err
+		}
+
+		wait := delay/2 + rand.N(delay/2+1)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			if statusErr.RetryAfter > c.retry.MaxDelay {
+				return err
+			}
+			wait = max(wait, statusErr.RetryAfter)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return err
+		}
+		log.Printf("%v; retrying in %s (attempt %d of %d)", err, wait.Round(time.Millisecond), n+1, attempts)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(delay*2, c.retry.MaxDelay)
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, attempt func(ctx context.Context) error) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	return attempt(ctx)
+}
+
+// retryable reports whether err is transient: a 429 or 5xx response, a
+// timeout, or a connection that was refused or dropped. Errors after the
+// caller's context has ended are not, even if the attempt itself timed
+// out. Anything else, such as a TLS or redirect error, would fail the
+// same way again.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
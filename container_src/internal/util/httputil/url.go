@@ -0,0 +1,106 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrNonPublicAddress is returned by PublicDialControl for a connection to
+// a loopback, private or otherwise non-public address.
+var ErrNonPublicAddress = errors.New("non-public address")
+
+// ValidatePublicURL checks that raw is an absolute http(s) URL whose host
+// resolves only to public addresses, so it is safe to hand to a service
+// that will fetch it on our behalf.
+func ValidatePublicURL(ctx context.Context, raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, errors.New("malformed URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("URL scheme must be http or https")
+	}
+	if u.User != nil {
+		return nil, errors.New("URL must not contain credentials")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return nil, errors.New("URL host is required")
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		return nil, errors.New("URL port must be 80 or 443")
+	}
+	lower := strings.ToLower(host)
+	if lower == "localhost" || strings.HasSuffix(lower, ".localhost") || strings.HasSuffix(lower, ".internal") {
+		return nil, errors.New("URL host is not allowed")
+	}
+
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{addr}
+	} else {
+		ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return nil, fmt.Errorf("resolve URL host: %w", err)
+		}
+		addrs = ips
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("URL host does not resolve")
+	}
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return nil, errors.New("URL host resolves to a non-public address")
+		}
+	}
+	return u, nil
+}
+
+// PublicDialControl is a net.Dialer Control hook that refuses connections
+// to non-public addresses. ValidatePublicURL only checks what a host
+// resolved to at the time; checking every connection also stops a host
+// that re-resolves to an internal address (DNS rebinding).
+func PublicDialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", address, err)
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("dial %s: %w", address, ErrNonPublicAddress)
+	}
+	return nil
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
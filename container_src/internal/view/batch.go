@@ -0,0 +1,92 @@
+package view
+
+import (
+	"fmt"
+	"time"
+
+	"server/internal/model"
+)
+
+type BatchSubmitResponse struct {
+	GroupID string     `json:"groupId"`
+	Jobs    []BatchJob `json:"jobs"`
+}
+
+type BatchStatusResponse struct {
+	GroupID   string        `json:"groupId"`
+	Status    string        `json:"status"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Progress  BatchProgress `json:"progress"`
+	Jobs      []BatchJob    `json:"jobs"`
+}
+
+type BatchProgress struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled"`
+}
+
+type BatchJob struct {
+	Filename string               `json:"filename"`
+	JobID    string               `json:"jobId,omitempty"`
+	Error    string               `json:"error,omitempty"`
+	Result   *SolveStatusResponse `json:"result,omitempty"`
+}
+
+func NewBatchSubmitResponse(g *model.JobGroup) BatchSubmitResponse {
+	resp := BatchSubmitResponse{GroupID: g.ID, Jobs: make([]BatchJob, len(g.Children))}
+	for i, child := range g.Children {
+		resp.Jobs[i] = toBatchJob(child)
+	}
+	return resp
+}
+
+func NewBatchStatusResponse(r *model.GroupResult) BatchStatusResponse {
+	resp := BatchStatusResponse{
+		GroupID:   r.Group.ID,
+		CreatedAt: r.Group.CreatedAt,
+		Progress:  BatchProgress{Total: len(r.Group.Children)},
+		Jobs:      make([]BatchJob, len(r.Group.Children)),
+	}
+
+	for i, child := range r.Group.Children {
+		job := toBatchJob(child)
+		if r.Errors[i] != "" {
+			job.Error = r.Errors[i]
+		}
+		result := r.Results[i]
+		switch {
+		case child.SubID == 0:
+			resp.Progress.Failed++
+		case !result.IsFinal():
+			resp.Progress.Pending++
+		case result.Status == model.StatusSuccess:
+			resp.Progress.Succeeded++
+		case result.Status == model.StatusCancelled:
+			resp.Progress.Cancelled++
+		default:
+			resp.Progress.Failed++
+		}
+		if result != nil {
+			status := NewSolveStatusResponse(result)
+			job.Result = &status
+		}
+		resp.Jobs[i] = job
+	}
+
+	resp.Status = "COMPLETED"
+	if resp.Progress.Pending > 0 {
+		resp.Status = "IN_PROGRESS"
+	}
+	return resp
+}
+
+func toBatchJob(child model.GroupChild) BatchJob {
+	job := BatchJob{Filename: child.Filename, Error: child.Error}
+	if child.SubID != 0 {
+		job.JobID = fmt.Sprintf("%d", child.SubID)
+	}
+	return job
+}
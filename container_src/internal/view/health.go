@@ -0,0 +1,41 @@
+package view
+
+import (
+	"time"
+
+	"server/internal/util/breaker"
+)
+
+type HealthResponse struct {
+	Status    string          `json:"status"`
+	Upstreams []UpstreamState `json:"upstreams"`
+}
+
+type UpstreamState struct {
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`
+	OpenedAt  *time.Time `json:"openedAt,omitempty"`
+	RetryAt   *time.Time `json:"retryAt,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+}
+
+// NewHealthResponse reports "degraded" while any upstream's breaker is not
+// closed.
+func NewHealthResponse(statuses []breaker.Status) HealthResponse {
+	resp := HealthResponse{Status: "ok", Upstreams: make([]UpstreamState, 0, len(statuses))}
+	for _, s := range statuses {
+		if s.State != breaker.StateClosed {
+			resp.Status = "degraded"
+		}
+		resp.Upstreams = append(resp.Upstreams, UpstreamState{
+			Name:      s.Name,
+			State:     string(s.State),
+			Failures:  s.Failures,
+			OpenedAt:  s.OpenedAt,
+			RetryAt:   s.RetryAt,
+			LastError: s.LastError,
+		})
+	}
+	return resp
+}
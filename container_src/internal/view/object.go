@@ -0,0 +1,37 @@
+package view
+
+import "server/internal/model"
+
+// ObjectResponse describes one object looked up by name. RA and Dec are
+// J2000 degrees.
+type ObjectResponse struct {
+	Type           string            `json:"type"`
+	Identifier     string            `json:"identifier"`
+	Name           string            `json:"name,omitempty"`
+	Aliases        []string          `json:"aliases,omitempty"`
+	RA             *float64          `json:"ra,omitempty"`
+	Dec            *float64          `json:"dec,omitempty"`
+	Constellation  *Constellation    `json:"constellation,omitempty"`
+	StarDetails    *StarDetails      `json:"starDetails,omitempty"`
+	DeepSkyDetails *DeepSkyDetails   `json:"deepSkyDetails,omitempty"`
+	Sources        map[string]string `json:"sources,omitempty"`
+}
+
+func NewObjectResponse(obj *model.IdentifiedObject) ObjectResponse {
+	v := ObjectResponse{
+		Type:          string(obj.Type),
+		Identifier:    obj.Identifier,
+		Name:          obj.Name,
+		Aliases:       obj.Aliases,
+		RA:            obj.RA,
+		Dec:           obj.Dec,
+		Constellation: toConstellation(obj.Constellation),
+		Sources:       obj.Sources,
+	}
+	if obj.Type == model.ObjectTypeStar {
+		v.StarDetails = toStarDetails(*obj)
+	} else {
+		v.DeepSkyDetails = toDeepSkyDetails(*obj)
+	}
+	return v
+}
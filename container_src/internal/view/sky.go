@@ -0,0 +1,35 @@
+package view
+
+import "server/internal/model"
+
+type ConeSearchResponse struct {
+	Objects   []NearbyObject `json:"objects"`
+	Total     int            `json:"total"`
+	Offset    int            `json:"offset"`
+	Limit     int            `json:"limit"`
+	Truncated bool           `json:"truncated"`
+}
+
+// NearbyObject is an object with its separation in degrees from the
+// cone's center.
+type NearbyObject struct {
+	ObjectResponse
+	Separation float64 `json:"separation"`
+}
+
+func NewConeSearchResponse(r *model.ConeResult, offset, limit int) ConeSearchResponse {
+	resp := ConeSearchResponse{
+		Objects:   make([]NearbyObject, 0, len(r.Objects)),
+		Total:     r.Total,
+		Offset:    offset,
+		Limit:     limit,
+		Truncated: r.Truncated,
+	}
+	for _, obj := range r.Objects {
+		resp.Objects = append(resp.Objects, NearbyObject{
+			ObjectResponse: NewObjectResponse(&obj.IdentifiedObject),
+			Separation:     obj.Separation,
+		})
+	}
+	return resp
+}
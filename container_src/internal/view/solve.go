@@ -1,13 +1,52 @@
 package view
 
 import (
+	"fmt"
 	"time"
 
 	"server/internal/model"
 )
 
 type SubmitResponse struct {
-	JobID string `json:"jobId"`
+	JobID        string `json:"jobId"`
+	Status       string `json:"status,omitempty"`
+	Deduplicated bool   `json:"deduplicated,omitempty"`
+	AutoHints    *Hints `json:"autoHints,omitempty"`
+}
+
+func NewSubmitResponse(s model.Submission) SubmitResponse {
+	return SubmitResponse{
+		JobID:        fmt.Sprintf("%d", s.SubID),
+		Status:       string(s.Status),
+		Deduplicated: s.Deduplicated,
+		AutoHints:    toHints(s.AutoHints),
+	}
+}
+
+// Hints reports solver hints using the same field names as the request.
+type Hints struct {
+	ScaleUnits       string   `json:"scale_units,omitempty"`
+	ScaleLower       *float64 `json:"scale_lower,omitempty"`
+	ScaleUpper       *float64 `json:"scale_upper,omitempty"`
+	CenterRA         *float64 `json:"center_ra,omitempty"`
+	CenterDec        *float64 `json:"center_dec,omitempty"`
+	Radius           *float64 `json:"radius,omitempty"`
+	DownsampleFactor *float64 `json:"downsample_factor,omitempty"`
+}
+
+func toHints(h *model.SolveHints) *Hints {
+	if h == nil {
+		return nil
+	}
+	return &Hints{
+		ScaleUnits:       string(h.ScaleUnits),
+		ScaleLower:       h.ScaleLower,
+		ScaleUpper:       h.ScaleUpper,
+		CenterRA:         h.CenterRA,
+		CenterDec:        h.CenterDec,
+		Radius:           h.Radius,
+		DownsampleFactor: h.DownsampleFactor,
+	}
 }
 
 type SolveStatusResponse struct {
@@ -15,18 +54,60 @@ type SolveStatusResponse struct {
 	Status            string             `json:"status"`
 	AnnotatedImageURL string             `json:"annotatedImageUrl,omitempty"`
 	IdentifiedObjects []IdentifiedObject `json:"identifiedObjects,omitempty"`
+	Calibration       *Calibration       `json:"calibration,omitempty"`
+	Image             *Image             `json:"image,omitempty"`
+	AutoHints         *Hints             `json:"autoHints,omitempty"`
 	CreatedAt         time.Time          `json:"createdAt"`
+	UpdatedAt         time.Time          `json:"updatedAt"`
+	CompletedAt       *time.Time         `json:"completedAt,omitempty"`
+	Timeline          []StatusEvent      `json:"timeline,omitempty"`
+	Callback          *Callback          `json:"callback,omitempty"`
+}
+
+type Callback struct {
+	URL         string     `json:"url"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+type Calibration struct {
+	RA           float64 `json:"ra"`
+	Dec          float64 `json:"dec"`
+	Radius       float64 `json:"radius"`
+	PixScale     float64 `json:"pixScale"`
+	Orientation  float64 `json:"orientation"`
+	Parity       float64 `json:"parity"`
+	WidthArcsec  float64 `json:"widthArcsec,omitempty"`
+	HeightArcsec float64 `json:"heightArcsec,omitempty"`
+}
+
+type Image struct {
+	Format         string `json:"format"`
+	Width          int    `json:"width"`
+	Height         int    `json:"height"`
+	OriginalWidth  int    `json:"originalWidth"`
+	OriginalHeight int    `json:"originalHeight"`
+}
+
+type StatusEvent struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
 }
 
 type IdentifiedObject struct {
-	Type           string          `json:"type"`
-	Identifier     string          `json:"identifier"`
-	Name           string          `json:"name,omitempty"`
-	Constellation  *Constellation  `json:"constellation,omitempty"`
-	XCoordinate    float64         `json:"xCoordinate"`
-	YCoordinate    float64         `json:"yCoordinate"`
-	StarDetails    *StarDetails    `json:"starDetails,omitempty"`
-	DeepSkyDetails *DeepSkyDetails `json:"deepSkyDetails,omitempty"`
+	Type           string            `json:"type"`
+	Identifier     string            `json:"identifier"`
+	Name           string            `json:"name,omitempty"`
+	Aliases        []string          `json:"aliases,omitempty"`
+	Constellation  *Constellation    `json:"constellation,omitempty"`
+	XCoordinate    float64           `json:"xCoordinate"`
+	YCoordinate    float64           `json:"yCoordinate"`
+	PositionSource string            `json:"positionSource,omitempty"`
+	StarDetails    *StarDetails      `json:"starDetails,omitempty"`
+	DeepSkyDetails *DeepSkyDetails   `json:"deepSkyDetails,omitempty"`
+	Sources        map[string]string `json:"sources,omitempty"`
 }
 
 type Constellation struct {
@@ -35,13 +116,36 @@ type Constellation struct {
 }
 
 type StarDetails struct {
-	VisualMagnitude *float64 `json:"visualMagnitude,omitempty"`
-	SpectralType    string   `json:"spectralType,omitempty"`
-	DistanceParsecs *float64 `json:"distanceParsecs,omitempty"`
+	VisualMagnitude      *float64           `json:"visualMagnitude,omitempty"`
+	Magnitudes           map[string]float64 `json:"magnitudes,omitempty"`
+	ColorIndexBV         *float64           `json:"colorIndexBV,omitempty"`
+	SpectralType         string             `json:"spectralType,omitempty"`
+	DistanceParsecs      *float64           `json:"distanceParsecs,omitempty"`
+	DistanceErrorParsecs *float64           `json:"distanceErrorParsecs,omitempty"`
+	ProperMotion         *ProperMotion      `json:"properMotion,omitempty"`
+	RadialVelocity       *float64           `json:"radialVelocity,omitempty"`
+}
+
+// ProperMotion is in mas/yr.
+type ProperMotion struct {
+	RA  float64 `json:"ra"`
+	Dec float64 `json:"dec"`
 }
 
 type DeepSkyDetails struct {
-	ObjectType string `json:"objectType"`
+	ObjectType      string             `json:"objectType"`
+	VisualMagnitude *float64           `json:"visualMagnitude,omitempty"`
+	Magnitudes      map[string]float64 `json:"magnitudes,omitempty"`
+	Size            *AngularSize       `json:"size,omitempty"`
+	RadialVelocity  *float64           `json:"radialVelocity,omitempty"`
+	Redshift        *float64           `json:"redshift,omitempty"`
+}
+
+// AngularSize gives the axes in arcmin and the position angle in degrees.
+type AngularSize struct {
+	MajorAxis     *float64 `json:"majorAxis,omitempty"`
+	MinorAxis     *float64 `json:"minorAxis,omitempty"`
+	PositionAngle *float64 `json:"positionAngle,omitempty"`
 }
 
 type CancelResponse struct {
@@ -54,10 +158,27 @@ func NewSolveStatusResponse(r *model.SolveResult) SolveStatusResponse {
 		JobID:             r.JobID,
 		Status:            string(r.Status),
 		AnnotatedImageURL: r.AnnotatedImageURL,
-		CreatedAt:         time.Now(),
+		CreatedAt:         r.CreatedAt,
+		UpdatedAt:         r.UpdatedAt,
+		CompletedAt:       r.CompletedAt,
+		AutoHints:         toHints(r.AutoHints),
+	}
+	for _, t := range r.History {
+		resp.Timeline = append(resp.Timeline, StatusEvent{Status: string(t.Status), At: t.At})
+	}
+	if r.Calibration != nil {
+		c := Calibration(*r.Calibration)
+		resp.Calibration = &c
+	}
+	if r.Image != nil {
+		img := Image(*r.Image)
+		resp.Image = &img
+	}
+	if r.Callback != nil {
+		resp.Callback = toCallback(r.Callback)
 	}
 	for _, obj := range r.Objects {
-		if obj.Type != model.ObjectTypeDSO && obj.XCoordinate == 0 && obj.YCoordinate == 0 {
+		if obj.Type != model.ObjectTypeDSO && obj.PositionSource == "" && obj.XCoordinate == 0 && obj.YCoordinate == 0 {
 			continue
 		}
 		resp.IdentifiedObjects = append(resp.IdentifiedObjects, toIdentifiedObject(obj))
@@ -65,30 +186,86 @@ func NewSolveStatusResponse(r *model.SolveResult) SolveStatusResponse {
 	return resp
 }
 
+func toCallback(c *model.CallbackState) *Callback {
+	v := &Callback{
+		URL:         c.URL,
+		Status:      "PENDING",
+		Attempts:    c.Attempts,
+		DeliveredAt: c.DeliveredAt,
+		LastError:   c.LastError,
+	}
+	switch {
+	case c.DeliveredAt != nil:
+		v.Status = "DELIVERED"
+	case c.DeadLetteredAt != nil:
+		v.Status = "FAILED"
+	}
+	return v
+}
+
 func toIdentifiedObject(obj model.IdentifiedObject) IdentifiedObject {
 	v := IdentifiedObject{
-		Type:        string(obj.Type),
-		Identifier:  obj.Identifier,
-		Name:        obj.Name,
-		XCoordinate: obj.XCoordinate,
-		YCoordinate: obj.YCoordinate,
-	}
-	if obj.Constellation != nil {
-		v.Constellation = &Constellation{
-			LatinName:   obj.Constellation.LatinName,
-			EnglishName: obj.Constellation.EnglishName,
-		}
+		Type:           string(obj.Type),
+		Identifier:     obj.Identifier,
+		Name:           obj.Name,
+		Aliases:        obj.Aliases,
+		XCoordinate:    obj.XCoordinate,
+		YCoordinate:    obj.YCoordinate,
+		PositionSource: string(obj.PositionSource),
+		Sources:        obj.Sources,
 	}
+	v.Constellation = toConstellation(obj.Constellation)
 	if obj.Type == model.ObjectTypeStar {
-		if obj.VMagnitude != nil || obj.SpectralClass != "" || obj.DistanceParsecs != nil {
-			v.StarDetails = &StarDetails{
-				VisualMagnitude: obj.VMagnitude,
-				SpectralType:    string(obj.SpectralClass),
-				DistanceParsecs: obj.DistanceParsecs,
-			}
-		}
-	} else if obj.DSOType != "" {
-		v.DeepSkyDetails = &DeepSkyDetails{ObjectType: string(obj.DSOType)}
+		v.StarDetails = toStarDetails(obj)
+	} else {
+		v.DeepSkyDetails = toDeepSkyDetails(obj)
 	}
 	return v
 }
+
+func toConstellation(c *model.Constellation) *Constellation {
+	if c == nil {
+		return nil
+	}
+	return &Constellation{LatinName: c.LatinName, EnglishName: c.EnglishName}
+}
+
+func toStarDetails(obj model.IdentifiedObject) *StarDetails {
+	if obj.VMagnitude == nil && obj.SpectralClass == "" && obj.DistanceParsecs == nil {
+		return nil
+	}
+	d := &StarDetails{
+		VisualMagnitude:      obj.VMagnitude,
+		Magnitudes:           obj.Magnitudes,
+		ColorIndexBV:         obj.ColorIndexBV,
+		SpectralType:         string(obj.SpectralClass),
+		DistanceParsecs:      obj.DistanceParsecs,
+		DistanceErrorParsecs: obj.DistanceErrorParsecs,
+		RadialVelocity:       obj.RadialVelocity,
+	}
+	if obj.ProperMotion != nil {
+		d.ProperMotion = &ProperMotion{RA: obj.ProperMotion.RA, Dec: obj.ProperMotion.Dec}
+	}
+	return d
+}
+
+func toDeepSkyDetails(obj model.IdentifiedObject) *DeepSkyDetails {
+	if obj.DSOType == "" {
+		return nil
+	}
+	d := &DeepSkyDetails{
+		ObjectType:      string(obj.DSOType),
+		VisualMagnitude: obj.VMagnitude,
+		Magnitudes:      obj.Magnitudes,
+		RadialVelocity:  obj.RadialVelocity,
+		Redshift:        obj.Redshift,
+	}
+	if obj.Size != nil {
+		d.Size = &AngularSize{
+			MajorAxis:     obj.Size.MajorAxis,
+			MinorAxis:     obj.Size.MinorAxis,
+			PositionAngle: obj.Size.PositionAngle,
+		}
+	}
+	return d
+}
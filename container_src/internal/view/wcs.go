@@ -0,0 +1,20 @@
+package view
+
+import "server/internal/model"
+
+type SkyPosition struct {
+	X             float64        `json:"x"`
+	Y             float64        `json:"y"`
+	RA            float64        `json:"ra"`
+	Dec           float64        `json:"dec"`
+	InImage       bool           `json:"inImage"`
+	Constellation *Constellation `json:"constellation,omitempty"`
+}
+
+func NewSkyPosition(x, y, ra, dec float64, inImage bool) SkyPosition {
+	v := SkyPosition{X: x, Y: y, RA: ra, Dec: dec, InImage: inImage}
+	if c := model.GetConstellationByCoords(ra, dec); c != nil {
+		v.Constellation = &Constellation{LatinName: c.LatinName, EnglishName: c.EnglishName}
+	}
+	return v
+}
@@ -0,0 +1,90 @@
+package wcs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const cardLength = 80
+
+// Header holds the keyword values of a FITS header. String values are
+// unquoted; numeric and logical values keep their literal text.
+type Header map[string]string
+
+// ParseHeader reads 80-column FITS header cards up to the END card.
+func ParseHeader(data []byte) (Header, error) {
+	h := make(Header)
+	for off := 0; off+cardLength <= len(data); off += cardLength {
+		card := string(data[off : off+cardLength])
+		key := strings.TrimSpace(card[:8])
+		if key == "END" {
+			return h, nil
+		}
+		if key == "" || card[8:10] != "= " {
+			continue
+		}
+		h[key] = parseValue(card[10:])
+	}
+	if len(h) == 0 {
+		return nil, errors.New("no FITS header cards found")
+	}
+	return h, nil
+}
+
+func parseValue(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "'") {
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			if raw[i] == '\'' {
+				if i+1 < len(raw) && raw[i+1] == '\'' {
+					b.WriteByte('\'')
+					i++
+					continue
+				}
+				break
+			}
+			b.WriteByte(raw[i])
+		}
+		return strings.TrimRight(b.String(), " ")
+	}
+	if idx := strings.Index(raw, "/"); idx != -1 {
+		raw = raw[:idx]
+	}
+	return strings.TrimSpace(raw)
+}
+
+// Float returns the numeric value of key. FITS allows D as an exponent marker.
+func (h Header) Float(key string) (float64, bool) {
+	raw, ok := h[key]
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.Replace(raw, "D", "E", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+func (h Header) Int(key string) (int, bool) {
+	v, ok := h.Float(key)
+	if !ok {
+		return 0, false
+	}
+	return int(v), true
+}
+
+func (h Header) String(key string) string {
+	return h[key]
+}
+
+func (h Header) requireFloat(key string) (float64, error) {
+	v, ok := h.Float(key)
+	if !ok {
+		return 0, fmt.Errorf("missing or invalid %s", key)
+	}
+	return v, nil
+}
@@ -0,0 +1,57 @@
+package wcs
+
+import (
+	"fmt"
+	"math"
+)
+
+// polynomial is one SIP distortion polynomial, Σ coef[p][q]·u^p·v^q.
+type polynomial struct {
+	order int
+	coef  [][]float64
+}
+
+func readPolynomial(h Header, name string) *polynomial {
+	order, ok := h.Int(name + "_ORDER")
+	if !ok || order < 0 || order > 9 {
+		return nil
+	}
+	poly := &polynomial{order: order, coef: make([][]float64, order+1)}
+	for p := 0; p <= order; p++ {
+		poly.coef[p] = make([]float64, order+1-p)
+		for q := 0; p+q <= order; q++ {
+			poly.coef[p][q], _ = h.Float(fmt.Sprintf("%s_%d_%d", name, p, q))
+		}
+	}
+	return poly
+}
+
+func (p *polynomial) eval(u, v float64) float64 {
+	var sum float64
+	up := 1.0
+	for i := 0; i <= p.order; i++ {
+		vq := 1.0
+		for j := 0; i+j <= p.order; j++ {
+			sum += p.coef[i][j] * up * vq
+			vq *= v
+		}
+		up *= u
+	}
+	return sum
+}
+
+// rescale returns the polynomial for coordinates sx and sy times larger
+// whose value is also s times larger: s·P(u/sx, v/sy).
+func (p *polynomial) rescale(s, sx, sy float64) *polynomial {
+	if p == nil {
+		return nil
+	}
+	r := &polynomial{order: p.order, coef: make([][]float64, len(p.coef))}
+	for i := range p.coef {
+		r.coef[i] = make([]float64, len(p.coef[i]))
+		for j := range p.coef[i] {
+			r.coef[i][j] = p.coef[i][j] * s / (math.Pow(sx, float64(i)) * math.Pow(sy, float64(j)))
+		}
+	}
+	return r
+}
@@ -0,0 +1,252 @@
+// Package wcs implements the gnomonic (TAN) World Coordinate System with
+// optional SIP distortion, as written by astrometry.net.
+//
+// Pixel coordinates follow the FITS convention: the center of the first
+// pixel is (1, 1). A point is mapped to the sky in four steps:
+//
+//  1. offset from the reference pixel: u = x - CRPIX1, v = y - CRPIX2
+//  2. SIP distortion: U = u + A(u, v), V = v + B(u, v)
+//  3. linear transform to intermediate world coordinates: (ξ, η) = CD · (U, V)
+//  4. gnomonic deprojection about the reference point (CRVAL1, CRVAL2)
+//
+// The reverse direction uses the AP/BP inverse polynomials when present and
+// falls back to fixed-point iteration of the forward polynomial otherwise.
+//
+// Reference: Shupe, D.L. et al. (2005), "The SIP Convention for
+// Representing Distortion in FITS Image Headers", ASP Conf. Ser. 347, 491.
+package wcs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const deg = math.Pi / 180
+
+type WCS struct {
+	CRPix [2]float64
+	CRVal [2]float64
+	CD    [2][2]float64
+
+	// ImageWidth and ImageHeight are the solved image size in pixels, or
+	// zero when the header does not record it.
+	ImageWidth  float64
+	ImageHeight float64
+
+	a, b   *polynomial
+	ap, bp *polynomial
+	cdInv  [2][2]float64
+}
+
+// Parse builds a WCS from a FITS header such as Nova's wcs_file.
+func Parse(data []byte) (*WCS, error) {
+	h, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	return FromHeader(h)
+}
+
+func FromHeader(h Header) (*WCS, error) {
+	ctype1, ctype2 := h.String("CTYPE1"), h.String("CTYPE2")
+	if !strings.HasPrefix(ctype1, "RA---TAN") || !strings.HasPrefix(ctype2, "DEC--TAN") {
+		return nil, fmt.Errorf("unsupported projection %q/%q", ctype1, ctype2)
+	}
+
+	w := &WCS{}
+	var err error
+	for i, key := range []string{"CRPIX1", "CRPIX2"} {
+		if w.CRPix[i], err = h.requireFloat(key); err != nil {
+			return nil, err
+		}
+	}
+	for i, key := range []string{"CRVAL1", "CRVAL2"} {
+		if w.CRVal[i], err = h.requireFloat(key); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.readLinear(h); err != nil {
+		return nil, err
+	}
+
+	if w.ImageWidth, _ = h.Float("IMAGEW"); w.ImageWidth == 0 {
+		w.ImageWidth, _ = h.Float("NAXIS1")
+	}
+	if w.ImageHeight, _ = h.Float("IMAGEH"); w.ImageHeight == 0 {
+		w.ImageHeight, _ = h.Float("NAXIS2")
+	}
+
+	if strings.HasSuffix(ctype1, "-SIP") {
+		w.a = readPolynomial(h, "A")
+		w.b = readPolynomial(h, "B")
+		w.ap = readPolynomial(h, "AP")
+		w.bp = readPolynomial(h, "BP")
+	}
+	return w, nil
+}
+
+func (w *WCS) readLinear(h Header) error {
+	cd11, ok11 := h.Float("CD1_1")
+	cd12, ok12 := h.Float("CD1_2")
+	cd21, ok21 := h.Float("CD2_1")
+	cd22, ok22 := h.Float("CD2_2")
+
+	if ok11 || ok12 || ok21 || ok22 {
+		w.CD = [2][2]float64{{cd11, cd12}, {cd21, cd22}}
+	} else {
+		cdelt1, ok1 := h.Float("CDELT1")
+		cdelt2, ok2 := h.Float("CDELT2")
+		if !ok1 || !ok2 {
+			return errors.New("missing CD matrix or CDELT")
+		}
+		pc := [2][2]float64{{1, 0}, {0, 1}}
+		if v, ok := h.Float("PC1_1"); ok {
+			pc[0][0] = v
+			pc[0][1], _ = h.Float("PC1_2")
+			pc[1][0], _ = h.Float("PC2_1")
+			pc[1][1], _ = h.Float("PC2_2")
+		} else if rot, ok := h.Float("CROTA2"); ok {
+			c, s := math.Cos(rot*deg), math.Sin(rot*deg)
+			pc = [2][2]float64{{c, -s * cdelt2 / cdelt1}, {s * cdelt1 / cdelt2, c}}
+		}
+		w.CD = [2][2]float64{
+			{cdelt1 * pc[0][0], cdelt1 * pc[0][1]},
+			{cdelt2 * pc[1][0], cdelt2 * pc[1][1]},
+		}
+	}
+
+	det := w.CD[0][0]*w.CD[1][1] - w.CD[0][1]*w.CD[1][0]
+	if det == 0 {
+		return errors.New("singular CD matrix")
+	}
+	w.cdInv = [2][2]float64{
+		{w.CD[1][1] / det, -w.CD[0][1] / det},
+		{-w.CD[1][0] / det, w.CD[0][0] / det},
+	}
+	return nil
+}
+
+// Rescale returns the solution for the same image sampled sx and sy times
+// more finely, such as the original of a downsampled frame. Pixel edges
+// are kept aligned, so the center of working pixel X maps to
+// (X - 0.5)·s + 0.5. Offsets from the reference pixel grow by s, which
+// the CD matrix and SIP coefficients are divided back out of.
+func (w *WCS) Rescale(sx, sy float64) *WCS {
+	if sx == 1 && sy == 1 {
+		return w
+	}
+	r := *w
+	r.CRPix = [2]float64{(w.CRPix[0]-0.5)*sx + 0.5, (w.CRPix[1]-0.5)*sy + 0.5}
+	r.CD = [2][2]float64{
+		{w.CD[0][0] / sx, w.CD[0][1] / sy},
+		{w.CD[1][0] / sx, w.CD[1][1] / sy},
+	}
+	r.cdInv = [2][2]float64{
+		{w.cdInv[0][0] * sx, w.cdInv[0][1] * sx},
+		{w.cdInv[1][0] * sy, w.cdInv[1][1] * sy},
+	}
+	r.ImageWidth, r.ImageHeight = w.ImageWidth*sx, w.ImageHeight*sy
+	r.a, r.ap = w.a.rescale(sx, sx, sy), w.ap.rescale(sx, sx, sy)
+	r.b, r.bp = w.b.rescale(sy, sx, sy), w.bp.rescale(sy, sx, sy)
+	return &r
+}
+
+// PixelToSky maps FITS pixel coordinates to J2000 RA/Dec in degrees.
+func (w *WCS) PixelToSky(x, y float64) (ra, dec float64) {
+	u, v := x-w.CRPix[0], y-w.CRPix[1]
+	if w.a != nil && w.b != nil {
+		u, v = u+w.a.eval(u, v), v+w.b.eval(u, v)
+	}
+
+	xi := (w.CD[0][0]*u + w.CD[0][1]*v) * deg
+	eta := (w.CD[1][0]*u + w.CD[1][1]*v) * deg
+
+	ra0, dec0 := w.CRVal[0]*deg, w.CRVal[1]*deg
+	denom := math.Cos(dec0) - eta*math.Sin(dec0)
+	ra = ra0 + math.Atan2(xi, denom)
+	dec = math.Atan2(math.Sin(dec0)+eta*math.Cos(dec0), math.Hypot(xi, denom))
+
+	return normalizeRA(ra / deg), dec / deg
+}
+
+// SkyToPixel maps RA/Dec in degrees to FITS pixel coordinates. ok is false
+// when the position lies on the far side of the tangent plane.
+func (w *WCS) SkyToPixel(ra, dec float64) (x, y float64, ok bool) {
+	ra0, dec0 := w.CRVal[0]*deg, w.CRVal[1]*deg
+	dra := ra*deg - ra0
+	sinDec, cosDec := math.Sincos(dec * deg)
+	sinDec0, cosDec0 := math.Sincos(dec0)
+
+	cosc := sinDec0*sinDec + cosDec0*cosDec*math.Cos(dra)
+	if cosc <= 0 {
+		return 0, 0, false
+	}
+	xi := cosDec * math.Sin(dra) / cosc / deg
+	eta := (cosDec0*sinDec - sinDec0*cosDec*math.Cos(dra)) / cosc / deg
+
+	U := w.cdInv[0][0]*xi + w.cdInv[0][1]*eta
+	V := w.cdInv[1][0]*xi + w.cdInv[1][1]*eta
+	u, v := w.undistort(U, V)
+
+	return u + w.CRPix[0], v + w.CRPix[1], true
+}
+
+// ImageToSky is PixelToSky for zero-based image coordinates, the
+// convention used by the API and by Nova annotations.
+func (w *WCS) ImageToSky(x, y float64) (ra, dec float64) {
+	return w.PixelToSky(x+1, y+1)
+}
+
+// SkyToImage is SkyToPixel returning zero-based image coordinates.
+func (w *WCS) SkyToImage(ra, dec float64) (x, y float64, ok bool) {
+	x, y, ok = w.SkyToPixel(ra, dec)
+	return x - 1, y - 1, ok
+}
+
+// Contains reports whether zero-based image coordinates (x, y) fall on the
+// image. It is always true when the image size is unknown.
+func (w *WCS) Contains(x, y float64) bool {
+	if w.ImageWidth == 0 || w.ImageHeight == 0 {
+		return true
+	}
+	return x >= -0.5 && x < w.ImageWidth-0.5 && y >= -0.5 && y < w.ImageHeight-0.5
+}
+
+func (w *WCS) undistort(U, V float64) (float64, float64) {
+	if w.a == nil || w.b == nil {
+		return U, V
+	}
+	u, v := U, V
+	if w.ap != nil && w.bp != nil {
+		u, v = U+w.ap.eval(U, V), V+w.bp.eval(U, V)
+	}
+	// Refine against the forward polynomial; AP/BP are only a fit.
+	for range 20 {
+		du := U - w.a.eval(u, v) - u
+		dv := V - w.b.eval(u, v) - v
+		u, v = u+du, v+dv
+		if math.Abs(du) < 1e-10 && math.Abs(dv) < 1e-10 {
+			break
+		}
+	}
+	return u, v
+}
+
+func normalizeRA(ra float64) float64 {
+	ra = math.Mod(ra, 360)
+	if ra < 0 {
+		ra += 360
+	}
+	return ra
+}
+
+// Separation returns the angle in degrees between two sky positions,
+// using the haversine formula so small angles stay accurate.
+func Separation(ra1, dec1, ra2, dec2 float64) float64 {
+	sinDDec := math.Sin((dec2 - dec1) * deg / 2)
+	sinDRA := math.Sin((ra2 - ra1) * deg / 2)
+	h := sinDDec*sinDDec + math.Cos(dec1*deg)*math.Cos(dec2*deg)*sinDRA*sinDRA
+	return 2 * math.Asin(math.Min(1, math.Sqrt(h))) / deg
+}
@@ -0,0 +1,163 @@
+package wcs_test
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"server/internal/wcs"
+)
+
+// novaHeader reads testdata/nova.wcs, a wcs_file as Nova writes it for a
+// 2000x1300 frame of M 42: TAN with second-order SIP distortion and its
+// AP/BP inverse.
+func novaHeader(t *testing.T) wcs.Header {
+	t.Helper()
+	data, err := os.ReadFile("testdata/nova.wcs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := wcs.ParseHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// variants returns the Nova solution as written, without its AP/BP
+// inverse so SkyToPixel has to iterate the forward polynomial from
+// scratch, and without SIP at all.
+func variants(t *testing.T) map[string]*wcs.WCS {
+	t.Helper()
+	sip := novaHeader(t)
+
+	forwardOnly := novaHeader(t)
+	for key := range forwardOnly {
+		if strings.HasPrefix(key, "AP_") || strings.HasPrefix(key, "BP_") {
+			delete(forwardOnly, key)
+		}
+	}
+
+	tan := novaHeader(t)
+	tan["CTYPE1"], tan["CTYPE2"] = "RA---TAN", "DEC--TAN"
+
+	out := make(map[string]*wcs.WCS)
+	for name, h := range map[string]wcs.Header{"TAN-SIP": sip, "TAN-SIP without AP/BP": forwardOnly, "TAN": tan} {
+		w, err := wcs.FromHeader(h)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		out[name] = w
+	}
+	return out
+}
+
+func TestParseNovaHeader(t *testing.T) {
+	data, err := os.ReadFile("testdata/nova.wcs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := wcs.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.CRPix != [2]float64{1006.69189453, 647.213989258} || w.CRVal != [2]float64{83.8188301254, -5.39004529531} {
+		t.Errorf("reference = %v at %v", w.CRVal, w.CRPix)
+	}
+	if w.CD[0][0] != -0.000455016556537 || w.CD[1][1] != -0.000454967493262 {
+		t.Errorf("CD = %v", w.CD)
+	}
+	if w.ImageWidth != 2000 || w.ImageHeight != 1300 {
+		t.Errorf("image = %vx%v, want 2000x1300", w.ImageWidth, w.ImageHeight)
+	}
+}
+
+func TestReferencePixel(t *testing.T) {
+	for name, w := range variants(t) {
+		ra, dec := w.PixelToSky(w.CRPix[0], w.CRPix[1])
+		if math.Abs(ra-w.CRVal[0]) > 1e-9 || math.Abs(dec-w.CRVal[1]) > 1e-9 {
+			t.Errorf("%s: reference pixel maps to %v, %v, want %v", name, ra, dec, w.CRVal)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for name, w := range variants(t) {
+		for _, x := range []float64{1, 250.5, 1006.69189453, 1500, 2000} {
+			for _, y := range []float64{1, 647.213989258, 1300} {
+				ra, dec := w.PixelToSky(x, y)
+				gotX, gotY, ok := w.SkyToPixel(ra, dec)
+				if !ok {
+					t.Errorf("%s: (%v, %v) is behind the tangent plane", name, x, y)
+					continue
+				}
+				if math.Abs(gotX-x) > 1e-6 || math.Abs(gotY-y) > 1e-6 {
+					t.Errorf("%s: (%v, %v) -> %v, %v -> (%v, %v)", name, x, y, ra, dec, gotX, gotY)
+				}
+			}
+		}
+	}
+}
+
+func TestDistortion(t *testing.T) {
+	ws := variants(t)
+	sip, forwardOnly, tan := ws["TAN-SIP"], ws["TAN-SIP without AP/BP"], ws["TAN"]
+
+	// At a corner the distortion is about half a pixel.
+	raSIP, decSIP := sip.PixelToSky(1, 1)
+	raTAN, decTAN := tan.PixelToSky(1, 1)
+	if sep := wcs.Separation(raSIP, decSIP, raTAN, decTAN) * 3600; sep < 0.5 {
+		t.Errorf("SIP moves the corner by %.2f arcsec, want about 0.7", sep)
+	}
+
+	// The inverse polynomial is only a starting point, so both paths
+	// converge on the same pixel.
+	x1, y1, _ := sip.SkyToPixel(raSIP, decSIP)
+	x2, y2, _ := forwardOnly.SkyToPixel(raSIP, decSIP)
+	if math.Abs(x1-x2) > 1e-6 || math.Abs(y1-y2) > 1e-6 {
+		t.Errorf("with AP/BP (%v, %v), without (%v, %v)", x1, y1, x2, y2)
+	}
+}
+
+func TestSkyToPixelFarSide(t *testing.T) {
+	w := variants(t)["TAN-SIP"]
+	if _, _, ok := w.SkyToPixel(w.CRVal[0]+180, -w.CRVal[1]); ok {
+		t.Error("antipode of the reference point projected onto the image")
+	}
+}
+
+func TestImageCoordinates(t *testing.T) {
+	w := variants(t)["TAN-SIP"]
+	ra1, dec1 := w.ImageToSky(0, 0)
+	ra2, dec2 := w.PixelToSky(1, 1)
+	if ra1 != ra2 || dec1 != dec2 {
+		t.Error("image (0, 0) is not FITS pixel (1, 1)")
+	}
+	if !w.Contains(0, 0) || !w.Contains(1999.4, 1299.4) || w.Contains(1999.5, 0) || w.Contains(0, -0.6) {
+		t.Error("Contains does not cover exactly the 2000x1300 image")
+	}
+}
+
+func TestRescale(t *testing.T) {
+	for name, w := range variants(t) {
+		r := w.Rescale(2, 3)
+		if r.ImageWidth != 4000 || r.ImageHeight != 3900 {
+			t.Errorf("%s: rescaled image = %vx%v", name, r.ImageWidth, r.ImageHeight)
+		}
+		for _, p := range [][2]float64{{0, 0}, {1005.69189453, 646.213989258}, {1999, 1299}, {400, 900}} {
+			// The center of working pixel x covers original pixels
+			// (x + 0.5)·s - 0.5, in zero-based image coordinates.
+			ox, oy := (p[0]+0.5)*2-0.5, (p[1]+0.5)*3-0.5
+			ra, dec := w.ImageToSky(p[0], p[1])
+			gotRA, gotDec := r.ImageToSky(ox, oy)
+			if sep := wcs.Separation(ra, dec, gotRA, gotDec) * 3600; sep > 1e-6 {
+				t.Errorf("%s: working %v and original (%v, %v) are %g arcsec apart", name, p, ox, oy, sep)
+			}
+			x, y, ok := r.SkyToImage(ra, dec)
+			if !ok || math.Abs(x-ox) > 1e-6 || math.Abs(y-oy) > 1e-6 {
+				t.Errorf("%s: %v maps back to (%v, %v), want (%v, %v)", name, p, x, y, ox, oy)
+			}
+		}
+	}
+}